@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/edgeopslabs/nexus/pkg/auth"
+	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/confirm"
+	"github.com/edgeopslabs/nexus/pkg/execution"
+	"github.com/edgeopslabs/nexus/pkg/policy"
+	"github.com/edgeopslabs/nexus/pkg/ratelimit"
+)
+
+const configUsage = `Usage:
+  nexus config validate [--config nexus.yaml] [--profile name] [--safe-mode]
+  nexus config print [--config nexus.yaml] [--profile name] [--resolved]`
+
+func runConfig(args []string) {
+	if len(args) == 0 || (args[0] != "validate" && args[0] != "print") {
+		fmt.Fprintln(os.Stderr, configUsage)
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("config "+args[0], flag.ExitOnError)
+	configPath := fs.String("config", "nexus.yaml", "path to nexus configuration file")
+	profile := fs.String("profile", "", "config profile to apply")
+	safeMode := false
+	resolved := false
+	if args[0] == "validate" {
+		fs.BoolVar(&safeMode, "safe-mode", false, "validate as if safe mode were enabled")
+	} else {
+		fs.BoolVar(&resolved, "resolved", false, "annotate each value with the file, profile or env variable it came from")
+	}
+	_ = fs.Parse(args[1:])
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, configUsage)
+		os.Exit(2)
+	}
+
+	opts := config.LoadOptions{Profile: *profile}
+	switch args[0] {
+	case "validate":
+		if !validateConfig(os.Stdout, *configPath, opts, safeMode) {
+			os.Exit(1)
+		}
+	case "print":
+		if err := printConfig(os.Stdout, *configPath, opts, resolved); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// printConfig writes the merged, defaulted config. Problems go to stderr
+// so the output stays valid YAML.
+func printConfig(w io.Writer, path string, opts config.LoadOptions, resolved bool) error {
+	cfg, err := config.Load(path, opts)
+	var invalid *config.ValidationError
+	if err != nil && !errors.As(err, &invalid) {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if invalid != nil {
+		fmt.Fprintf(os.Stderr, "warning: config problems (see nexus config validate):\n%v\n", err)
+	}
+	if resolved {
+		fmt.Fprintf(w, "# files: %s\n", strings.Join(cfg.Files(), ", "))
+		if cfg.Profile() != "" {
+			fmt.Fprintf(w, "# profile: %s\n", cfg.Profile())
+		}
+	}
+	out, err := cfg.Dump(resolved)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// validateConfig loads the file the way the server does, then builds every
+// component that compiles config at startup, and prints what fails.
+func validateConfig(w io.Writer, path string, opts config.LoadOptions, safeMode bool) bool {
+	cfg, err := config.Load(path, opts)
+	var invalid *config.ValidationError
+	if err != nil && !errors.As(err, &invalid) {
+		fmt.Fprintf(w, "%s: %v\n", path, err)
+		return false
+	}
+	var problems []string
+	if invalid != nil {
+		for _, p := range invalid.Problems {
+			problems = append(problems, p.String())
+		}
+	}
+	if safeMode {
+		cfg.Server.SafeMode = true
+	}
+
+	checks := []struct {
+		section string
+		check   func() error
+	}{
+		{"policy", func() error {
+			_, err := policy.NewProfiles(cfg.Policy, cfg.Server.SafeMode)
+			return err
+		}},
+		{"rate_limits", func() error {
+			_, err := ratelimit.New(cfg.RateLimits)
+			return err
+		}},
+		{"execution", func() error {
+			_, err := execution.New(cfg.Execution)
+			return err
+		}},
+		{"confirm", func() error {
+			_, err := confirm.New(cfg.Confirm)
+			return err
+		}},
+		{"server.auth", func() error {
+			_, err := auth.New(cfg.Server.Auth)
+			return err
+		}},
+	}
+	for _, c := range checks {
+		if err := c.check(); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s: %s", path, c.section, cfg.Redact(err.Error())))
+		}
+	}
+
+	for _, p := range problems {
+		fmt.Fprintln(w, p)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(w, "%d problem(s) found\n", len(problems))
+		return false
+	}
+	fmt.Fprintf(w, "%s: ok\n", path)
+	return true
+}
+
+// logConfigProblems reports a *config.ValidationError from LoadConfig one
+// problem per line, as errors when strict and warnings otherwise.
+func logConfigProblems(err error, strict bool) {
+	var invalid *config.ValidationError
+	if !errors.As(err, &invalid) {
+		return
+	}
+	level := slog.LevelWarn
+	if strict {
+		level = slog.LevelError
+	}
+	for _, p := range invalid.Problems {
+		slog.Log(context.Background(), level, "config problem", "file", p.File, "line", p.Line, "column", p.Column, "key", p.Path, "problem", p.Message)
+	}
+}
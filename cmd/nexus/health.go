@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/health"
+	"github.com/edgeopslabs/nexus/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	coreModuleName = "nexus"
+	healthTool     = "nexus_health"
+)
+
+// coreModule serves tools about Nexus itself. It is not in the registry, so
+// it cannot be disabled, but its tools still go through policy and audit.
+type coreModule struct {
+	check func(context.Context) health.Report
+}
+
+func (m *coreModule) Name() string {
+	return coreModuleName
+}
+
+func (m *coreModule) Init(_ *config.Config) error {
+	return nil
+}
+
+func (m *coreModule) GetTools() []mcp.Tool {
+	return []mcp.Tool{
+		mcp.NewTool(healthTool,
+			mcp.WithDescription("Report which Nexus backends (Kubernetes, Prometheus, Docker, plugins) are reachable. Call before other tools to avoid backends that are down."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+		),
+	}
+}
+
+func (m *coreModule) HandleCall(ctx context.Context, name string, _ map[string]interface{}) (*mcp.CallToolResult, error) {
+	switch name {
+	case healthTool:
+		report := m.check(ctx)
+		result := mcp.NewToolResultText(formatReport(report))
+		result.StructuredContent = report
+		return result, nil
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("unknown tool: %s", name)), nil
+	}
+}
+
+// HealthCheck always passes: if this runs, the server is up.
+func (m *coreModule) HealthCheck(_ context.Context) error {
+	return nil
+}
+
+func formatReport(report health.Report) string {
+	var b strings.Builder
+	if report.Ready {
+		b.WriteString("nexus: ready\n")
+	} else {
+		fmt.Fprintf(&b, "nexus: not ready (down: %s)\n", strings.Join(report.Down(), ", "))
+	}
+	for _, m := range report.Modules {
+		fmt.Fprintf(&b, "- %s: %s (%dms)", m.Module, m.Status, m.LatencyMs)
+		if m.Error != "" {
+			fmt.Fprintf(&b, ": %s", m.Error)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+var (
+	_ types.NexusModule   = (*coreModule)(nil)
+	_ types.HealthChecker = (*coreModule)(nil)
+)
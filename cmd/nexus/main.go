@@ -1,24 +1,34 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/edgeopslabs/nexus/pkg/audit"
+	"github.com/edgeopslabs/nexus/pkg/auth"
+	"github.com/edgeopslabs/nexus/pkg/certs"
 	"github.com/edgeopslabs/nexus/pkg/common"
 	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/confirm"
+	"github.com/edgeopslabs/nexus/pkg/execution"
+	"github.com/edgeopslabs/nexus/pkg/health"
+	"github.com/edgeopslabs/nexus/pkg/lifecycle"
+	"github.com/edgeopslabs/nexus/pkg/metrics"
 	"github.com/edgeopslabs/nexus/pkg/plugins"
 	"github.com/edgeopslabs/nexus/pkg/policy"
+	"github.com/edgeopslabs/nexus/pkg/ratelimit"
 	"github.com/edgeopslabs/nexus/pkg/registry"
-	"github.com/edgeopslabs/nexus/pkg/types"
-	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/edgeopslabs/nexus/pkg/tracing"
 	"github.com/mark3labs/mcp-go/server"
 
 	_ "github.com/edgeopslabs/nexus/pkg/modules/docker"
@@ -33,39 +43,77 @@ func main() {
 		runInstall(os.Args[2:])
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "policy" {
+		runPolicy(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		runConfig(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "schema" {
+		runSchema(os.Args[2:])
+		return
+	}
 
 	common.PrintBanner()
 
 	configPath := flag.String("config", "nexus.yaml", "path to nexus configuration file")
 	safeMode := flag.Bool("safe-mode", false, "run in read-only safe mode")
-	transport := flag.String("transport", "stdio", "transport: stdio or sse")
-	httpAddr := flag.String("http-addr", ":8080", "http listen address for sse transport")
+	transport := flag.String("transport", "stdio", "transport: stdio, sse or http")
+	httpAddr := flag.String("http-addr", ":8080", "http listen address for sse/http transports")
 	baseURL := flag.String("base-url", "", "base URL for sse endpoint (e.g. http://localhost:8080)")
-	basePath := flag.String("base-path", "/mcp", "base path for sse endpoints")
+	basePath := flag.String("base-path", "/mcp", "base path for sse/http endpoints")
+	profile := flag.String("profile", "", "config profile (profiles: entry) to apply, or policy profile (policy.profiles entry) for callers no identity binds, e.g. stdio")
+	strict := flag.Bool("strict", false, "refuse to start (or reload) when the config file has problems")
 	flag.Parse()
 
-	cfg, err := config.LoadConfig(*configPath)
+	cfg, err := config.Load(*configPath, config.LoadOptions{Profile: *profile})
 	if *safeMode {
 		cfg.Server.SafeMode = true
 	}
 	configureLogging(cfg)
-	if err != nil {
-		if os.IsNotExist(err) {
-			slog.Warn("config file not found, using defaults", "path", *configPath)
-		} else {
-			slog.Warn("failed to load config, using defaults", "path", *configPath, "error", err)
+	var invalid *config.ValidationError
+	switch {
+	case err == nil:
+	case errors.As(err, &invalid):
+		logConfigProblems(err, *strict)
+		if *strict {
+			slog.Error("invalid config; refusing to start (--strict)", "path", *configPath, "problems", len(invalid.Problems))
+			os.Exit(1)
 		}
+	case *strict:
+		slog.Error("failed to load config; refusing to start (--strict)", "path", *configPath, "error", err)
+		os.Exit(1)
+	case os.IsNotExist(err):
+		slog.Warn("config file not found, using defaults", "path", *configPath)
+	default:
+		slog.Warn("failed to load config, using defaults", "path", *configPath, "error", err)
 	}
 	if cfg.Server.SafeMode {
 		slog.Warn("safe mode enabled (read-only)")
 	}
 
+	policies, err := policy.NewProfiles(cfg.Policy, cfg.Server.SafeMode)
+	if err != nil {
+		slog.Error("invalid policy configuration", "path", *configPath, "error", err)
+		os.Exit(1)
+	}
+	if err := selectProfile(policies, cfg, *profile); err != nil {
+		slog.Error("invalid profile", "error", err)
+		os.Exit(1)
+	}
+	rt := &toolRuntime{transport: strings.ToLower(*transport)}
+
 	// 1. Initialize the Nexus Server
 	s := server.NewMCPServer(
 		cfg.Server.Name,
 		cfg.Server.Version,
 		server.WithResourceCapabilities(true, true),
+		server.WithToolCapabilities(true),
 		server.WithLogging(),
+		server.WithElicitation(),
+		server.WithToolFilter(rt.filterTools),
 	)
 
 	modules, err := registry.LoadModules(cfg)
@@ -74,28 +122,165 @@ func main() {
 		os.Exit(1)
 	}
 
-	toolPolicy := policy.New(cfg.Policy, cfg.Server.SafeMode)
-	toolSummaries := collectToolSummaries(modules, toolPolicy)
-	registerTools(s, modules, toolPolicy)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if strings.ToLower(*transport) == "sse" {
-		startSSEServer(s, cfg.Server.Name, cfg.Server.Version, toolSummaries, *httpAddr, *baseURL, *basePath)
-		return
+	shutdownTimeout := parseDuration(cfg.Server.ShutdownTimeout, 30*time.Second)
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, cfg.Server.Name, cfg.Server.Version)
+	if err != nil {
+		slog.Error("failed to configure tracing", "error", err)
+		os.Exit(1)
+	}
+	auditLog, err := audit.New(cfg.Audit)
+	if err != nil {
+		slog.Error("failed to configure audit log", "error", err)
+		os.Exit(1)
 	}
+	defer auditLog.Close()
 
-	// 3. Start the Server (Stdio Transport)
-	// AI Agents (Claude/Cursor) talk to this binary via Stdin/Stdout
-	fmt.Fprintln(os.Stderr, "🔌 Nexus is connecting to the matrix...")
+	confirmer, err := confirm.New(cfg.Confirm)
+	if err != nil {
+		slog.Error("invalid confirm configuration", "error", err)
+		os.Exit(1)
+	}
 
-	if err := server.ServeStdio(s); err != nil {
-		slog.Error("server error", "error", err)
+	limiter, err := ratelimit.New(cfg.RateLimits)
+	if err != nil {
+		slog.Error("invalid rate_limits configuration", "error", err)
 		os.Exit(1)
 	}
+
+	limits, err := execution.New(cfg.Execution)
+	if err != nil {
+		slog.Error("invalid execution configuration", "error", err)
+		os.Exit(1)
+	}
+
+	mode := rt.transport
+	var approvals http.Handler
+	if queue := confirmer.Queue(); queue != nil {
+		approvals = queue.Handler("/approvals")
+		if cfg.Confirm.Queue.Addr != "" {
+			go serveApprovals(ctx, cfg, approvals, shutdownTimeout)
+			approvals = nil
+		} else if mode == "stdio" {
+			slog.Warn("confirm queue has no listener; set confirm.queue.addr to approve calls over stdio")
+		}
+	}
+
+	if cfg.Server.Metrics.Enabled && cfg.Server.Metrics.Addr != "" {
+		go serveMetrics(ctx, cfg.Server.Metrics.Addr, shutdownTimeout)
+	}
+
+	tracker := lifecycle.NewTracker()
+	rt.tracker = tracker
+	rt.audit = auditLog
+	rt.confirm = confirmer
+	rt.healthTimeout = parseDuration(cfg.Server.HealthTimeout, 5*time.Second)
+	core := &coreModule{check: rt.checkHealth}
+	rt.apply(s, &runtimeState{
+		policies: policies,
+		limiter:  limiter,
+		limits:   limits,
+		modules:  append(modules, core),
+		cfg:      cfg,
+	})
+	reloads := newReloader(*configPath, *safeMode, *strict, *profile, cfg, s, rt, core)
+	go reloads.run(ctx)
+
+	var serveErr error
+	switch mode {
+	case "sse", "http":
+		serveErr = startHTTPServer(ctx, s, cfg, mode, rt.toolSummaries, rt.checkHealth, *httpAddr, *baseURL, *basePath, tracker, approvals, shutdownTimeout)
+	case "stdio":
+		// 3. Start the Server (Stdio Transport)
+		// AI Agents (Claude/Cursor) talk to this binary via Stdin/Stdout
+		fmt.Fprintln(os.Stderr, "🔌 Nexus is connecting to the matrix...")
+		serveErr = serveStdio(ctx, s, tracker, shutdownTimeout)
+	default:
+		slog.Error("unknown transport", "transport", *transport)
+		os.Exit(2)
+	}
+
+	closeCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := registry.Close(closeCtx); err != nil {
+		slog.Error("module teardown failed", "error", err)
+	}
+	if err := shutdownTracing(closeCtx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
+	}
+	if serveErr != nil {
+		slog.Error("server error", "error", serveErr)
+		auditLog.Close()
+		os.Exit(1)
+	}
+	slog.Info("nexus stopped")
+}
+
+func serveStdio(ctx context.Context, s *server.MCPServer, tracker *lifecycle.Tracker, shutdownTimeout time.Duration) error {
+	stdioServer := server.NewStdioServer(s)
+	errCh := make(chan error, 1)
+	go func() {
+		// Listen gets its own context so a signal drains calls instead of
+		// cancelling them outright; see drainCalls.
+		errCh <- stdioServer.Listen(context.Background(), os.Stdin, os.Stdout)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	drainCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	drainCalls(drainCtx, tracker)
+	return nil
+}
+
+func drainCalls(ctx context.Context, tracker *lifecycle.Tracker) {
+	slog.Info("shutting down; draining in-flight tool calls", "inFlight", tracker.InFlight())
+	if err := tracker.Drain(ctx); err != nil {
+		slog.Warn("drain deadline reached; cancelled remaining tool calls", "error", err)
+	}
+}
+
+// selectProfile resolves --profile, which names either a config profile
+// (a profiles: entry, already applied by config.Load) or a policy profile (a
+// policy.profiles entry, made the default for callers no identity binds). A
+// name that is both is ambiguous.
+func selectProfile(policies *policy.Profiles, cfg *config.Config, profile string) error {
+	if profile == "" {
+		return nil
+	}
+	_, isPolicy := policies.Get(profile)
+	switch {
+	case cfg.Profile() == profile && isPolicy:
+		return fmt.Errorf("--profile: %q names both a config profile and a policy profile; rename one of them", profile)
+	case cfg.Profile() == profile:
+		return nil
+	case isPolicy:
+		return policies.SetDefault(profile)
+	}
+	return fmt.Errorf("--profile: unknown profile %q: not an entry of profiles: or policy.profiles", profile)
+}
+
+func parseDuration(value string, def time.Duration) time.Duration {
+	if value == "" {
+		return def
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		slog.Warn("invalid duration; using default", "value", value, "default", def)
+		return def
+	}
+	return parsed
 }
 
 func configureLogging(cfg *config.Config) {
 	level := parseLogLevel(cfg.Server.LogLevel)
-	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	logger := slog.New(redactHandler{next: handler, cfg: cfg})
 	slog.SetDefault(logger)
 }
 
@@ -112,116 +297,181 @@ func parseLogLevel(level string) slog.Level {
 	}
 }
 
-func registerTools(s *server.MCPServer, modules []types.NexusModule, toolPolicy *policy.Policy) {
-	for _, module := range modules {
-		mod := module
-		for _, tool := range mod.GetTools() {
-			toolName := tool.Name
-			name := toolName
-			decision := toolPolicy.Evaluate(mod.Name(), toolName)
-			if decision == policy.Deny {
-				slog.Warn("tool blocked by policy", "module", mod.Name(), "tool", toolName)
-				continue
-			}
-
-			s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-				callDecision := toolPolicy.Evaluate(mod.Name(), name)
-				if callDecision == policy.Deny {
-					return mcp.NewToolResultError("tool blocked by policy"), nil
-				}
-				if callDecision == policy.Confirm {
-					if !confirmTool(mod.Name(), name) {
-						return mcp.NewToolResultError("tool execution denied by user"), nil
-					}
-				}
-				args, ok := request.Params.Arguments.(map[string]interface{})
-				if !ok {
-					args = make(map[string]interface{})
-				}
-				return mod.HandleCall(ctx, name, args)
-			})
-			slog.Info("tool registered", "module", mod.Name(), "tool", toolName)
-		}
+func startHTTPServer(ctx context.Context, mcpServer *server.MCPServer, cfg *config.Config, transport string, tools func(context.Context) []toolSummary, ready func(context.Context) health.Report, addr, baseURL, basePath string, tracker *lifecycle.Tracker, approvals http.Handler, shutdownTimeout time.Duration) error {
+	authn, err := auth.New(cfg.Server.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to configure authentication: %w", err)
+	}
+	if len(authn) == 0 {
+		slog.Warn("authentication disabled; anyone who can reach the listener can call tools", "addr", addr)
 	}
-}
-
-type toolSummary struct {
-	Module      string `json:"module"`
-	Name        string `json:"name"`
-	Description string `json:"description,omitempty"`
-	Status      string `json:"status"`
-}
-
-type toolInventory struct {
-	Server    string        `json:"server"`
-	Version   string        `json:"version"`
-	Transport string        `json:"transport"`
-	Tools     []toolSummary `json:"tools"`
-}
 
-func collectToolSummaries(modules []types.NexusModule, toolPolicy *policy.Policy) []toolSummary {
-	var summaries []toolSummary
-	for _, module := range modules {
-		for _, tool := range module.GetTools() {
-			decision := toolPolicy.Evaluate(module.Name(), tool.Name)
-			status := "allowed"
-			if decision == policy.Confirm {
-				status = "confirm"
-			} else if decision == policy.Deny {
-				status = "denied"
-			}
-			summaries = append(summaries, toolSummary{
-				Module:      module.Name(),
-				Name:        tool.Name,
-				Description: tool.Description,
-				Status:      status,
-			})
+	scheme := "http"
+	var reloader *certs.Reloader
+	if cfg.Server.TLS.Enabled() {
+		reloader, err = certs.NewReloader(cfg.Server.TLS)
+		if err != nil {
+			return fmt.Errorf("failed to configure tls: %w", err)
 		}
+		scheme = "https"
+		go reloader.Watch(ctx)
 	}
-	return summaries
-}
 
-func startSSEServer(mcpServer *server.MCPServer, name, version string, tools []toolSummary, addr, baseURL, basePath string) {
-	if baseURL == "" {
-		baseURL = "http://localhost" + addr
+	mux := http.NewServeMux()
+	switch transport {
+	case "sse":
+		if baseURL == "" {
+			baseURL = scheme + "://localhost" + addr
+		}
+		sseServer := server.NewSSEServer(
+			mcpServer,
+			server.WithBaseURL(baseURL),
+			server.WithStaticBasePath(basePath),
+			server.WithSSEEndpoint("/sse"),
+			server.WithMessageEndpoint("/message"),
+			server.WithUseFullURLForMessageEndpoint(true),
+			server.WithKeepAlive(true),
+		)
+		mux.Handle(basePath+"/sse", auth.Middleware(authn, sseServer.SSEHandler()))
+		mux.Handle(basePath+"/message", auth.Middleware(authn, sseServer.MessageHandler()))
+	case "http":
+		streamableServer := server.NewStreamableHTTPServer(
+			mcpServer,
+			server.WithEndpointPath(basePath),
+			server.WithHeartbeatInterval(30*time.Second),
+		)
+		mux.Handle(basePath, auth.Middleware(authn, streamableServer))
 	}
 
-	sseServer := server.NewSSEServer(
-		mcpServer,
-		server.WithBaseURL(baseURL),
-		server.WithStaticBasePath(basePath),
-		server.WithSSEEndpoint("/sse"),
-		server.WithMessageEndpoint("/message"),
-		server.WithUseFullURLForMessageEndpoint(true),
-		server.WithKeepAlive(true),
-	)
-
-	mux := http.NewServeMux()
-	mux.Handle(basePath+"/sse", sseServer.SSEHandler())
-	mux.Handle(basePath+"/message", sseServer.MessageHandler())
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
 	})
-	mux.HandleFunc("/tools", func(w http.ResponseWriter, _ *http.Request) {
+	mux.Handle("/readyz", health.Handler(ready))
+	if cfg.Server.Metrics.Enabled && cfg.Server.Metrics.Addr == "" {
+		mux.Handle("/metrics", metrics.Handler())
+	}
+	mux.Handle("/tools", auth.Middleware(authn, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		payload := toolInventory{
-			Server:    name,
-			Version:   version,
-			Transport: "sse",
-			Tools:     tools,
+			Server:    cfg.Redact(cfg.Server.Name),
+			Version:   cfg.Redact(cfg.Server.Version),
+			Transport: transport,
+			Tools:     redactSummaries(cfg, tools(r.Context())),
+			Degraded:  registry.Degraded(),
+		}
+		for i := range payload.Degraded {
+			payload.Degraded[i].Error = cfg.Redact(payload.Degraded[i].Error)
 		}
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(payload)
-	})
+	})))
+	if approvals != nil && len(authn) == 0 {
+		// Every caller would be anonymous, so an agent could approve its
+		// own calls.
+		slog.Error("approval queue not served on the MCP listener without authentication; set confirm.queue.addr")
+	} else if approvals != nil {
+		mux.Handle("/approvals", auth.Middleware(authn, approvals))
+		mux.Handle("/approvals/", auth.Middleware(authn, approvals))
+	}
 
-	slog.Info("starting sse server", "addr", addr, "baseURL", baseURL, "basePath", basePath)
+	slog.Info("starting http server", "transport", transport, "addr", addr, "tls", reloader != nil, "baseURL", baseURL, "basePath", basePath)
 	httpServer := &http.Server{
 		Addr:    addr,
 		Handler: mux,
 	}
+	errCh := make(chan error, 1)
+	go func() {
+		if reloader != nil {
+			httpServer.TLSConfig = reloader.TLSConfig()
+			errCh <- httpServer.ListenAndServeTLS("", "")
+			return
+		}
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != nil && err != http.ErrServerClosed {
+			return fmt.Errorf("%s server: %w", transport, err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	drainCalls(shutdownCtx, tracker)
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		// Long-lived SSE/stream connections never go idle; drop them.
+		slog.Warn("http server did not shut down cleanly; closing connections", "error", err)
+		_ = httpServer.Close()
+	}
+	return nil
+}
+
+// serveMetrics exposes /metrics on its own listener, for stdio or to keep
+// scrapes off the MCP port.
+func serveMetrics(ctx context.Context, addr string, shutdownTimeout time.Duration) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	httpServer := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("starting metrics server", "addr", addr)
 	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		slog.Error("sse server error", "error", err)
-		os.Exit(1)
+		slog.Error("metrics server", "error", err)
+	}
+}
+
+// serveApprovals exposes the confirm queue on its own listener, so calls
+// made over stdio can still be approved by an operator.
+func serveApprovals(ctx context.Context, cfg *config.Config, approvals http.Handler, shutdownTimeout time.Duration) {
+	addr := cfg.Confirm.Queue.Addr
+	authn, err := auth.New(cfg.Server.Auth)
+	if err != nil {
+		slog.Error("approval queue disabled: failed to configure authentication", "error", err)
+		return
+	}
+	if len(authn) == 0 {
+		slog.Warn("authentication disabled; anyone who can reach the approval queue can approve calls", "addr", addr)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/approvals", auth.Middleware(authn, approvals))
+	mux.Handle("/approvals/", auth.Middleware(authn, approvals))
+	httpServer := &http.Server{Addr: addr, Handler: mux}
+
+	var reloader *certs.Reloader
+	if cfg.Server.TLS.Enabled() {
+		reloader, err = certs.NewReloader(cfg.Server.TLS)
+		if err != nil {
+			slog.Error("approval queue disabled: failed to configure tls", "error", err)
+			return
+		}
+		httpServer.TLSConfig = reloader.TLSConfig()
+		go reloader.Watch(ctx)
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("starting approval queue", "addr", addr, "tls", reloader != nil)
+	if reloader != nil {
+		err = httpServer.ListenAndServeTLS("", "")
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		slog.Error("approval queue server", "error", err)
 	}
 }
 
@@ -243,18 +493,3 @@ func runInstall(args []string) {
 	}
 	fmt.Fprintf(os.Stderr, "Installed plugin bundle at %s\n", installedPath)
 }
-
-func confirmTool(module, tool string) bool {
-	tty, err := os.OpenFile(filepath.Clean("/dev/tty"), os.O_RDWR, 0)
-	if err != nil {
-		slog.Warn("confirmation unavailable; denying tool", "module", module, "tool", tool, "error", err)
-		return false
-	}
-	defer tty.Close()
-
-	_, _ = fmt.Fprintf(tty, "Confirm execution of %s/%s [y/N]: ", module, tool)
-	reader := bufio.NewReader(tty)
-	line, _ := reader.ReadString('\n')
-	response := strings.TrimSpace(strings.ToLower(line))
-	return response == "y" || response == "yes"
-}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/auth"
+	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/policy"
+	"github.com/edgeopslabs/nexus/pkg/registry"
+	"github.com/edgeopslabs/nexus/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+const policyUsage = `Usage:
+  nexus policy explain [--config nexus.yaml] [--safe-mode] [--profile name] [--arg key=value]... [--identity method:subject] <module/tool>
+  nexus policy test [--config nexus.yaml] [--safe-mode] [--profile name] <cases.yaml>`
+
+// policyCase is one row of a `nexus policy test` table.
+type policyCase struct {
+	Name        string         `yaml:"name"`
+	Tool        string         `yaml:"tool"` // module/tool
+	Args        map[string]any `yaml:"args"`
+	Identity    *caseIdentity  `yaml:"identity"`
+	Profile     string         `yaml:"profile"`     // forces a profile instead of resolving it from identity
+	Time        string         `yaml:"time"`        // RFC 3339; defaults to now
+	ReadOnly    *bool          `yaml:"read_only"`   // overrides the tool's annotation
+	Destructive *bool          `yaml:"destructive"` // overrides the tool's annotation
+	Expect      string         `yaml:"expect"`
+}
+
+type caseIdentity struct {
+	Subject string         `yaml:"subject"`
+	Method  string         `yaml:"method"`
+	Claims  map[string]any `yaml:"claims"`
+}
+
+type policyCases struct {
+	Cases []policyCase `yaml:"cases"`
+}
+
+type argFlags map[string]any
+
+func (a argFlags) String() string {
+	return fmt.Sprint(map[string]any(a))
+}
+
+func (a argFlags) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	a[key] = val
+	return nil
+}
+
+func runPolicy(args []string) {
+	if len(args) == 0 || (args[0] != "explain" && args[0] != "test") {
+		fmt.Fprintln(os.Stderr, policyUsage)
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("policy "+args[0], flag.ExitOnError)
+	configPath := fs.String("config", "nexus.yaml", "path to nexus configuration file")
+	safeMode := fs.Bool("safe-mode", false, "evaluate as if safe mode were enabled")
+	profile := fs.String("profile", "", "config profile to apply, or policy profile for callers no identity binds")
+	callArgs := argFlags{}
+	identity := ""
+	if args[0] == "explain" {
+		fs.Var(callArgs, "arg", "call argument as key=value (repeatable)")
+		fs.StringVar(&identity, "identity", "", "caller as subject or method:subject for identity-based rules and profiles")
+	}
+	_ = fs.Parse(args[1:])
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, policyUsage)
+		os.Exit(2)
+	}
+
+	// Keep module init chatter out of the report.
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	cfg, err := config.Load(*configPath, config.LoadOptions{Profile: *profile})
+	var invalid *config.ValidationError
+	if errors.As(err, &invalid) {
+		fmt.Fprintf(os.Stderr, "warning: config problems (see nexus config validate):\n%v\n", err)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config %s: %v\n", *configPath, err)
+		os.Exit(1)
+	}
+	if *safeMode {
+		cfg.Server.SafeMode = true
+	}
+	policies, err := policy.NewProfiles(cfg.Policy, cfg.Server.SafeMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid policy configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if err := selectProfile(policies, cfg, *profile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	modules, err := registry.LoadModules(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v; evaluating by name only\n", err)
+	}
+
+	passed := true
+	switch args[0] {
+	case "explain":
+		req := policy.Request{Args: map[string]any(callArgs), Time: time.Now().UTC()}
+		if identity != "" {
+			req.Identity = parseIdentity(identity)
+		}
+		found := resolveTool(modules, fs.Arg(0), &req)
+		profileName, toolPolicy := policies.Resolve(req.Identity)
+		printExplanation(os.Stdout, req, profileName, toolPolicy.Explain(req), found)
+	case "test":
+		passed = runPolicyCases(os.Stdout, policies, modules, fs.Arg(0))
+	}
+
+	// Module Init may have opened clients or started plugin setup.
+	if err := registry.Close(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if !passed {
+		os.Exit(1)
+	}
+}
+
+// resolveTool fills req.Module, req.Tool and req.Annotations from a
+// "module/tool" or bare tool name, reporting whether an enabled module
+// defines the tool. Unless the name picks an instance (prometheus@eu/...),
+// an instance argument picks it as it does for a real call, and the module
+// fills in default arguments as it does before policy runs.
+func resolveTool(modules []types.NexusModule, name string, req *policy.Request) bool {
+	module, tool, qualified := strings.Cut(name, "/")
+	if !qualified {
+		module, tool = "", name
+	}
+	req.Module, req.Tool = module, tool
+	wantInstance, _ := req.Args[instanceArg].(string)
+	for _, mod := range modules {
+		base, instance := types.SplitInstance(mod.Name())
+		if module != "" && module != mod.Name() && module != base {
+			continue
+		}
+		if module != mod.Name() && instance != "" && wantInstance != "" && instance != wantInstance {
+			continue
+		}
+		for _, def := range mod.GetTools() {
+			if def.Name != tool {
+				continue
+			}
+			req.Module = mod.Name()
+			req.Annotations = def.Annotations
+			if defaulter, ok := mod.(types.ArgDefaulter); ok {
+				if req.Args == nil {
+					req.Args = make(map[string]any)
+				}
+				defaulter.DefaultArgs(tool, req.Args)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// parseIdentity accepts "subject" or "method:subject".
+func parseIdentity(value string) *auth.Identity {
+	if method, subject, ok := strings.Cut(value, ":"); ok {
+		return &auth.Identity{Method: method, Subject: subject}
+	}
+	return &auth.Identity{Subject: value}
+}
+
+func printExplanation(w io.Writer, req policy.Request, profile string, explanation policy.Explanation, found bool) {
+	matched := explanation.Matched
+	if matched == "" {
+		matched = "(no entry matched; default allow)"
+	}
+	fmt.Fprintf(w, "tool:     %s/%s\n", req.Module, req.Tool)
+	if profile != "" {
+		fmt.Fprintf(w, "profile:  %s\n", profile)
+	}
+	fmt.Fprintf(w, "decision: %s\n", explanation.Decision)
+	if explanation.Reason != "" {
+		fmt.Fprintf(w, "reason:   %s\n", explanation.Reason)
+	}
+	fmt.Fprintf(w, "matched:  %s\n", matched)
+	if !found {
+		fmt.Fprintln(w, "note:     tool not found in enabled modules; annotations unknown, evaluated by name")
+	}
+}
+
+func runPolicyCases(w io.Writer, policies *policy.Profiles, modules []types.NexusModule, path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(w, "failed to read %s: %v\n", path, err)
+		return false
+	}
+	var suite policyCases
+	if err := yaml.Unmarshal(data, &suite); err != nil {
+		fmt.Fprintf(w, "failed to parse %s: %v\n", path, err)
+		return false
+	}
+
+	passed, failed := 0, 0
+	for i, tc := range suite.Cases {
+		name := tc.Name
+		if name == "" {
+			name = fmt.Sprintf("cases[%d] %s", i, tc.Tool)
+		}
+		expect, err := policy.ParseDecision(tc.Expect)
+		if err != nil {
+			fmt.Fprintf(w, "FAIL %s: expect: %v\n", name, err)
+			failed++
+			continue
+		}
+
+		req := policy.Request{Args: tc.Args, Time: time.Now().UTC()}
+		if tc.Time != "" {
+			parsed, err := time.Parse(time.RFC3339, tc.Time)
+			if err != nil {
+				fmt.Fprintf(w, "FAIL %s: time: %v\n", name, err)
+				failed++
+				continue
+			}
+			req.Time = parsed
+		}
+		if tc.Identity != nil {
+			req.Identity = &auth.Identity{Subject: tc.Identity.Subject, Method: tc.Identity.Method, Claims: tc.Identity.Claims}
+		}
+		resolveTool(modules, tc.Tool, &req)
+		if tc.ReadOnly != nil {
+			req.Annotations.ReadOnlyHint = tc.ReadOnly
+		}
+		if tc.Destructive != nil {
+			req.Annotations.DestructiveHint = tc.Destructive
+		}
+
+		toolPolicy, ok := policies.Get(tc.Profile)
+		if tc.Profile == "" {
+			_, toolPolicy = policies.Resolve(req.Identity)
+		} else if !ok {
+			fmt.Fprintf(w, "FAIL %s: unknown profile %q\n", name, tc.Profile)
+			failed++
+			continue
+		}
+		explanation := toolPolicy.Explain(req)
+		matched := explanation.Matched
+		if matched == "" {
+			matched = "default"
+		}
+		if explanation.Decision != expect {
+			fmt.Fprintf(w, "FAIL %s: expected %s, got %s (%s)\n", name, expect, explanation.Decision, matched)
+			failed++
+			continue
+		}
+		fmt.Fprintf(w, "ok   %s: %s (%s)\n", name, explanation.Decision, matched)
+		passed++
+	}
+
+	fmt.Fprintf(w, "%d passed, %d failed\n", passed, failed)
+	return failed == 0
+}
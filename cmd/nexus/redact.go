@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+// redactHandler masks config secrets in log messages and attributes before
+// they reach the wrapped handler.
+type redactHandler struct {
+	next slog.Handler
+	cfg  *config.Config
+}
+
+func (h redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.cfg.Redact(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.attr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.attr(attr)
+	}
+	return redactHandler{next: h.next.WithAttrs(redacted), cfg: h.cfg}
+}
+
+func (h redactHandler) WithGroup(name string) slog.Handler {
+	return redactHandler{next: h.next.WithGroup(name), cfg: h.cfg}
+}
+
+func (h redactHandler) attr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, h.cfg.Redact(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i, member := range group {
+			redacted[i] = h.attr(member)
+		}
+		return slog.Group(attr.Key, redacted...)
+	case slog.KindAny:
+		text := fmt.Sprint(value.Any())
+		if masked := h.cfg.Redact(text); masked != text {
+			return slog.String(attr.Key, masked)
+		}
+	}
+	return attr
+}
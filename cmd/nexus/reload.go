@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/execution"
+	"github.com/edgeopslabs/nexus/pkg/policy"
+	"github.com/edgeopslabs/nexus/pkg/ratelimit"
+	"github.com/edgeopslabs/nexus/pkg/registry"
+	"github.com/edgeopslabs/nexus/pkg/types"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// reloader re-reads the config file on SIGHUP and, when server.config_watch
+// is set, whenever the file's content changes. Policy, rate limits,
+// execution limits, log level and modules are applied live; other sections
+// only take effect on restart. It also retries degraded modules, so retries
+// never overlap a reload.
+type reloader struct {
+	path     string
+	safeMode bool   // --safe-mode
+	strict   bool   // --strict
+	profile  string // --profile
+
+	server *server.MCPServer
+	rt     *toolRuntime
+	core   types.NexusModule
+
+	cfg    *config.Config // last applied
+	digest [sha256.Size]byte
+}
+
+func newReloader(path string, safeMode, strict bool, profile string, cfg *config.Config, s *server.MCPServer, rt *toolRuntime, core types.NexusModule) *reloader {
+	r := &reloader{
+		path:     path,
+		safeMode: safeMode,
+		strict:   strict,
+		profile:  profile,
+		server:   s,
+		rt:       rt,
+		core:     core,
+		cfg:      cfg,
+	}
+	r.digest = configDigest(path)
+	return r
+}
+
+func (r *reloader) run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var poll <-chan time.Time
+	if interval := parseDuration(r.cfg.Server.ConfigWatch, 0); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		poll = ticker.C
+		slog.Info("watching config file for changes", "path", r.path, "interval", interval)
+	}
+
+	for {
+		// Reloads can degrade or recover modules, so rearm after each event.
+		var retry <-chan time.Time
+		if at := registry.NextRetry(); !at.IsZero() {
+			retry = time.After(time.Until(at))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-retry:
+			r.retry()
+		case <-hup:
+			r.digest = configDigest(r.path)
+			r.reload(ctx, "sighup")
+		case <-poll:
+			digest := configDigest(r.path)
+			if digest == r.digest {
+				continue
+			}
+			r.digest = digest
+			r.reload(ctx, "file change")
+		}
+	}
+}
+
+// reload applies the config file. Anything invalid leaves the running
+// config untouched; only module init failures are applied partially, by
+// dropping or degrading the failed module.
+func (r *reloader) reload(ctx context.Context, trigger string) {
+	cfg, err := config.Load(r.path, config.LoadOptions{Profile: r.profile})
+	var invalid *config.ValidationError
+	if errors.As(err, &invalid) && !r.strict {
+		logConfigProblems(err, false)
+	} else if err != nil {
+		logConfigProblems(err, true)
+		slog.Error("config reload failed; keeping current config", "path", r.path, "trigger", trigger, "error", err)
+		return
+	}
+	if r.safeMode {
+		cfg.Server.SafeMode = true
+	}
+
+	policies, err := policy.NewProfiles(cfg.Policy, cfg.Server.SafeMode)
+	if err == nil {
+		err = selectProfile(policies, cfg, r.profile)
+	}
+	if err != nil {
+		slog.Error("config reload failed: invalid policy configuration; keeping current config", "error", err)
+		return
+	}
+	limiter, err := ratelimit.New(cfg.RateLimits)
+	if err != nil {
+		slog.Error("config reload failed: invalid rate_limits configuration; keeping current config", "error", err)
+		return
+	}
+	limits, err := execution.New(cfg.Execution)
+	if err != nil {
+		slog.Error("config reload failed: invalid execution configuration; keeping current config", "error", err)
+		return
+	}
+
+	// Keep budgets, quotas and busy slots across the reload.
+	current := r.rt.state.Load()
+	limiter.Inherit(current.limiter)
+	limits.Inherit(current.limits)
+
+	modules, err := registry.Reload(ctx, cfg)
+	if err != nil {
+		slog.Error("module reload failed", "error", err)
+	}
+	configureLogging(cfg)
+	r.rt.apply(r.server, &runtimeState{
+		policies: policies,
+		limiter:  limiter,
+		limits:   limits,
+		modules:  append(modules, r.core),
+		cfg:      cfg,
+	})
+	if sections := restartRequired(r.cfg, cfg); len(sections) > 0 {
+		slog.Warn("config changes need a restart to take effect", "sections", sections)
+	}
+	r.cfg = cfg
+	slog.Info("config reloaded", "path", r.path, "trigger", trigger, "safeMode", cfg.Server.SafeMode)
+}
+
+// retry re-initializes the degraded modules that are due and, when one
+// recovers, adds its tools under the current policy and limits.
+func (r *reloader) retry() {
+	modules, recovered := registry.Retry(time.Now())
+	if !recovered {
+		return
+	}
+	st := r.rt.state.Load()
+	r.rt.apply(r.server, &runtimeState{
+		policies: st.policies,
+		limiter:  st.limiter,
+		limits:   st.limits,
+		modules:  append(modules, r.core),
+		cfg:      st.cfg,
+	})
+}
+
+// restartRequired lists changed sections that are only read at startup.
+func restartRequired(before, after *config.Config) []string {
+	var sections []string
+	b, a := before.Server, after.Server
+	b.SafeMode, a.SafeMode = false, false
+	b.LogLevel, a.LogLevel = "", ""
+	if !reflect.DeepEqual(b, a) {
+		sections = append(sections, "server")
+	}
+	if !reflect.DeepEqual(before.Audit, after.Audit) {
+		sections = append(sections, "audit")
+	}
+	if !reflect.DeepEqual(before.Confirm, after.Confirm) {
+		sections = append(sections, "confirm")
+	}
+	if !reflect.DeepEqual(before.Tracing, after.Tracing) {
+		sections = append(sections, "tracing")
+	}
+	return sections
+}
+
+// configDigest hashes every file config.Load would merge for path, so
+// edits to includes and conf.d files count as changes too.
+func configDigest(path string) [sha256.Size]byte {
+	h := sha256.New()
+	for _, file := range config.Files(path) {
+		data, _ := os.ReadFile(file)
+		fmt.Fprintf(h, "%s\x00%d\x00", file, len(data))
+		h.Write(data)
+	}
+	var digest [sha256.Size]byte
+	copy(digest[:], h.Sum(nil))
+	return digest
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/plugins"
+	"github.com/invopop/jsonschema"
+)
+
+const schemaUsage = `Usage:
+  nexus schema config     JSON Schema of nexus.yaml
+  nexus schema manifest   JSON Schema of a plugin nexus.yaml manifest`
+
+func runSchema(args []string) {
+	var s *jsonschema.Schema
+	switch {
+	case len(args) == 1 && args[0] == "config":
+		s = config.Schema()
+	case len(args) == 1 && args[0] == "manifest":
+		s = plugins.Schema()
+	default:
+		fmt.Fprintln(os.Stderr, schemaUsage)
+		os.Exit(2)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(s); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
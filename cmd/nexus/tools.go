@@ -0,0 +1,505 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/audit"
+	"github.com/edgeopslabs/nexus/pkg/auth"
+	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/confirm"
+	"github.com/edgeopslabs/nexus/pkg/execution"
+	"github.com/edgeopslabs/nexus/pkg/health"
+	"github.com/edgeopslabs/nexus/pkg/lifecycle"
+	"github.com/edgeopslabs/nexus/pkg/metrics"
+	"github.com/edgeopslabs/nexus/pkg/policy"
+	"github.com/edgeopslabs/nexus/pkg/ratelimit"
+	"github.com/edgeopslabs/nexus/pkg/registry"
+	"github.com/edgeopslabs/nexus/pkg/tracing"
+	"github.com/edgeopslabs/nexus/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// toolRuntime holds everything the per-call wrapper needs around
+// NexusModule.HandleCall.
+type toolRuntime struct {
+	state     atomic.Pointer[runtimeState]
+	tracker   *lifecycle.Tracker
+	audit     *audit.Logger
+	confirm   *confirm.Chain
+	transport string
+
+	healthTimeout time.Duration
+}
+
+// runtimeState is the part of the runtime a config reload replaces. It is
+// swapped as a unit, so a call never mixes old policy with new modules.
+type runtimeState struct {
+	policies *policy.Profiles
+	limiter  *ratelimit.Limiter
+	limits   *execution.Limits
+	modules  []types.NexusModule
+	cfg      *config.Config            // masks its secrets in health reports
+	tools    map[string]registeredTool // registered tool name -> owner
+}
+
+// registeredTool is a tool and the module that serves it. A tool of a
+// module with instances is registered once, with an instance argument that
+// selects the module serving each call.
+type registeredTool struct {
+	module    types.NexusModule
+	tool      mcp.Tool
+	instances map[string]types.NexusModule // instance name -> module; nil without instances
+}
+
+// resolve picks the module that serves a call.
+func (e registeredTool) resolve(args map[string]interface{}) (types.NexusModule, error) {
+	if e.instances == nil {
+		return e.module, nil
+	}
+	name, _ := args[instanceArg].(string)
+	if module, ok := e.instances[name]; ok {
+		return module, nil
+	}
+	return nil, fmt.Errorf("%s must be one of %s", instanceArg, strings.Join(slices.Sorted(maps.Keys(e.instances)), ", "))
+}
+
+// modules lists every module that can serve the tool.
+func (e registeredTool) modules() []types.NexusModule {
+	if e.instances == nil {
+		return []types.NexusModule{e.module}
+	}
+	modules := make([]types.NexusModule, 0, len(e.instances))
+	for _, name := range slices.Sorted(maps.Keys(e.instances)) {
+		modules = append(modules, e.instances[name])
+	}
+	return modules
+}
+
+// instanceArg selects the module instance for tools of modules that have
+// several, e.g. one Prometheus per region.
+const instanceArg = "instance"
+
+// withInstanceArg adds the required instance argument to a copy of tool.
+func withInstanceArg(tool mcp.Tool, module string, instances []string) mcp.Tool {
+	properties := make(map[string]any, len(tool.InputSchema.Properties)+1)
+	for key, value := range tool.InputSchema.Properties {
+		properties[key] = value
+	}
+	properties[instanceArg] = map[string]any{
+		"type":        "string",
+		"enum":        instances,
+		"description": fmt.Sprintf("The %s instance to use: %s.", module, strings.Join(instances, ", ")),
+	}
+	tool.InputSchema.Properties = properties
+	tool.InputSchema.Required = append(slices.Clip(tool.InputSchema.Required), instanceArg)
+	return tool
+}
+
+// apply installs st and brings the server's tool list in line with it:
+// every tool that at least one reachable policy profile permits is added
+// (filterTools then narrows tools/list to the caller's profile) and tools
+// that disappeared are removed. Connected clients get tools/list_changed.
+func (rt *toolRuntime) apply(s *server.MCPServer, st *runtimeState) {
+	st.tools = make(map[string]registeredTool)
+	var names []string
+	for _, module := range st.modules {
+		mod := module
+		base, instance := types.SplitInstance(mod.Name())
+		for _, tool := range moduleTools(mod) {
+			toolName := tool.Name
+			if !permittedByAny(st.policies, mod.Name(), tool) {
+				slog.Warn("tool blocked by policy", "module", mod.Name(), "tool", toolName)
+				continue
+			}
+			if owner, exists := st.tools[toolName]; exists {
+				if ownerBase, _ := types.SplitInstance(owner.module.Name()); owner.instances != nil && instance != "" && ownerBase == base {
+					owner.instances[instance] = mod
+					continue
+				}
+				slog.Warn("duplicate tool name; keeping first", "tool", toolName, "module", mod.Name(), "owner", owner.module.Name())
+				continue
+			}
+
+			entry := registeredTool{module: mod, tool: tool}
+			if instance != "" {
+				entry.instances = map[string]types.NexusModule{instance: mod}
+			}
+			st.tools[toolName] = entry
+			names = append(names, toolName)
+		}
+	}
+
+	var serverTools []server.ServerTool
+	for _, toolName := range names {
+		entry := st.tools[toolName]
+		if entry.instances != nil {
+			base, _ := types.SplitInstance(entry.module.Name())
+			entry.tool = withInstanceArg(entry.tool, base, slices.Sorted(maps.Keys(entry.instances)))
+			st.tools[toolName] = entry
+		}
+		serverTools = append(serverTools, server.ServerTool{Tool: entry.tool, Handler: rt.handler(toolName)})
+		for _, mod := range entry.modules() {
+			slog.Info("tool registered", "module", mod.Name(), "tool", toolName)
+		}
+	}
+
+	previous := rt.state.Swap(st)
+	var removed []string
+	if previous != nil {
+		for name := range previous.tools {
+			if _, ok := st.tools[name]; !ok {
+				removed = append(removed, name)
+				slog.Info("tool removed", "tool", name)
+			}
+		}
+	}
+	if len(removed) > 0 {
+		s.DeleteTools(removed...)
+	}
+	s.AddTools(serverTools...)
+}
+
+// moduleTools lists a module's tools, sorted by name, without racing a
+// reload's Init. With modules in init order this keeps registration, logs
+// and /tools stable across starts.
+func moduleTools(module types.NexusModule) []mcp.Tool {
+	release := registry.Enter(module.Name())
+	defer release()
+	tools := module.GetTools()
+	sort.SliceStable(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools
+}
+
+func permittedByAny(policies *policy.Profiles, module string, tool mcp.Tool) bool {
+	for _, p := range policies.All() {
+		if p.EvaluateTool(module, tool) != policy.Deny {
+			return true
+		}
+	}
+	return false
+}
+
+// policyFor resolves the caller's policy profile from the request context.
+func (st *runtimeState) policyFor(ctx context.Context) (string, *policy.Policy) {
+	return st.policies.Resolve(auth.FromContext(ctx))
+}
+
+// filterTools is the tools/list filter: callers only see what their profile
+// does not deny.
+func (rt *toolRuntime) filterTools(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	st := rt.state.Load()
+	_, p := st.policyFor(ctx)
+	filtered := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		entry, ok := st.tools[tool.Name]
+		if !ok {
+			continue
+		}
+		for _, mod := range entry.modules() {
+			if p.EvaluateTool(mod.Name(), tool) != policy.Deny {
+				filtered = append(filtered, tool)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func (rt *toolRuntime) toolSummaries(ctx context.Context) []toolSummary {
+	st := rt.state.Load()
+	_, p := st.policyFor(ctx)
+	return collectToolSummaries(st.modules, p)
+}
+
+// checkHealth checks every loaded module, including the core one, with
+// config secrets masked in the errors.
+func (rt *toolRuntime) checkHealth(ctx context.Context) health.Report {
+	st := rt.state.Load()
+	return health.Check(ctx, st.modules, rt.healthTimeout).Redact(st.cfg.Redact)
+}
+
+// handler resolves the tool on every call, so a reload that replaces the
+// owning module or policy takes effect without re-registering handlers.
+func (rt *toolRuntime) handler(name string) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		st := rt.state.Load()
+		args, ok := request.Params.Arguments.(map[string]interface{})
+		if !ok {
+			args = make(map[string]interface{})
+		}
+		event := audit.Event{
+			Time:      start.UTC(),
+			Transport: rt.transport,
+			Session:   sessionID(ctx),
+			Identity:  auth.FromContext(ctx).String(),
+			Tool:      name,
+			Args:      args,
+		}
+		entry, ok := st.tools[name]
+		if !ok {
+			return rt.reject(event, fmt.Sprintf("unknown tool: %s", name)), nil
+		}
+		mod, err := entry.resolve(args)
+		if err != nil {
+			return rt.reject(event, err.Error()), nil
+		}
+		event.Module = mod.Name()
+		if defaulter, ok := mod.(types.ArgDefaulter); ok {
+			release := registry.Enter(mod.Name())
+			defaulter.DefaultArgs(name, args)
+			release()
+		}
+		tool := entry.tool
+
+		ctx = tracing.FromMeta(ctx, request.Params.Meta)
+		ctx, span := tracing.Start(ctx, "tools/call "+name,
+			attribute.String("nexus.module", mod.Name()),
+			attribute.String("nexus.tool", name),
+		)
+
+		result, err := rt.call(ctx, st, mod, tool, args, &event)
+
+		event.DurationMs = time.Since(start).Milliseconds()
+		event.ResultBytes = resultSize(result)
+		event.Error = callError(result, err)
+		rt.audit.Record(event)
+		observeCall(event, time.Since(start))
+
+		span.SetAttributes(
+			attribute.String("nexus.identity", event.Identity),
+			attribute.String("nexus.decision", event.Decision),
+			attribute.Int("nexus.result_bytes", event.ResultBytes),
+		)
+		var spanErr error
+		if event.Error != "" {
+			spanErr = errors.New(event.Error)
+		}
+		tracing.End(span, spanErr)
+		return result, err
+	}
+}
+
+// reject records a call refused before it reached policy, such as one for
+// an unknown tool or instance, and returns its error result.
+func (rt *toolRuntime) reject(event audit.Event, reason string) *mcp.CallToolResult {
+	event.Decision = "rejected"
+	event.Reason = reason
+	event.Error = reason
+	event.DurationMs = time.Since(event.Time).Milliseconds()
+	rt.audit.Record(event)
+	observeCall(event, time.Since(event.Time))
+	return mcp.NewToolResultError(reason)
+}
+
+// observeCall reports a recorded call to the metrics package.
+func observeCall(event audit.Event, duration time.Duration) {
+	metrics.ObserveCall(metrics.Call{
+		Module:        event.Module,
+		Tool:          event.Tool,
+		Decision:      event.Decision,
+		Confirm:       event.Confirm,
+		ConfirmMethod: event.ConfirmMethod,
+		Failed:        event.Error != "",
+		ResultBytes:   event.ResultBytes,
+		Duration:      duration,
+	})
+}
+
+func (rt *toolRuntime) call(ctx context.Context, st *runtimeState, mod types.NexusModule, tool mcp.Tool, args map[string]interface{}, event *audit.Event) (*mcp.CallToolResult, error) {
+	ctx, done, err := rt.tracker.Begin(ctx)
+	if err != nil {
+		event.Decision = "rejected"
+		event.Reason = err.Error()
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	defer done()
+
+	name := tool.Name
+	profile, callPolicy := st.policyFor(ctx)
+	event.Profile = profile
+	verdict := callPolicy.EvaluateCall(policy.Request{
+		Module:      mod.Name(),
+		Tool:        name,
+		Args:        args,
+		Identity:    auth.FromContext(ctx),
+		Annotations: tool.Annotations,
+		Time:        event.Time,
+	})
+	event.Decision = verdict.Decision.String()
+	event.Reason = verdict.Reason
+	if verdict.Decision == policy.Deny {
+		return mcp.NewToolResultError(blockedMessage(verdict.Reason)), nil
+	}
+
+	if err := st.limiter.Allow(mod.Name(), name, auth.FromContext(ctx), event.Time); err != nil {
+		event.Decision = "rate_limited"
+		event.Reason = err.Error()
+		return rateLimitedResult(err), nil
+	}
+
+	if verdict.Decision == policy.Confirm {
+		outcome := rt.confirm.Confirm(ctx, confirm.Request{
+			Module:   mod.Name(),
+			Tool:     name,
+			Args:     rt.audit.Redact(args),
+			Identity: auth.FromContext(ctx),
+			Session:  event.Session,
+			Reason:   verdict.Reason,
+		})
+		event.ConfirmMethod = outcome.Method
+		event.ConfirmedBy = outcome.By
+		if !outcome.Approved {
+			event.Confirm = "denied"
+			if outcome.Detail != "" {
+				return mcp.NewToolResultError("tool execution denied: " + outcome.Detail), nil
+			}
+			return mcp.NewToolResultError("tool execution denied by user"), nil
+		}
+		event.Confirm = "approved"
+	}
+
+	slog.Debug("tool call", "module", mod.Name(), "tool", name, "identity", event.Identity)
+	var result *mcp.CallToolResult
+	err = st.limits.Run(ctx, mod.Name(), name, func(ctx context.Context) error {
+		release := registry.Enter(mod.Name())
+		defer release()
+		var callErr error
+		result, callErr = mod.HandleCall(ctx, name, args)
+		return callErr
+	})
+	var timeout *execution.TimeoutError
+	if errors.As(err, &timeout) {
+		return mcp.NewToolResultError(timeout.Error()), nil
+	}
+	if ctx.Err() != nil {
+		// Run gave up on a cancelled call that may still be writing result.
+		return nil, err
+	}
+	return result, err
+}
+
+// rateLimitedResult tells the agent when it may retry, both as text and as
+// structured content it can act on.
+func rateLimitedResult(err error) *mcp.CallToolResult {
+	result := mcp.NewToolResultError(err.Error())
+	var limited *ratelimit.LimitedError
+	if errors.As(err, &limited) {
+		result.StructuredContent = map[string]any{
+			"error":               "rate_limited",
+			"kind":                limited.Kind,
+			"limit":               limited.Limit,
+			"retry_after_seconds": limited.RetryAfterSeconds(),
+		}
+	}
+	return result
+}
+
+func blockedMessage(reason string) string {
+	if reason == "" {
+		return "tool blocked by policy"
+	}
+	return "tool blocked by policy: " + reason
+}
+
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+func resultSize(result *mcp.CallToolResult) int {
+	if result == nil {
+		return 0
+	}
+	size := 0
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			size += len(text.Text)
+			continue
+		}
+		if data, err := json.Marshal(content); err == nil {
+			size += len(data)
+		}
+	}
+	return size
+}
+
+func callError(result *mcp.CallToolResult, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	if result == nil || !result.IsError {
+		return ""
+	}
+	var parts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+type toolSummary struct {
+	Module      string `json:"module"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Status      string `json:"status"`
+}
+
+type toolInventory struct {
+	Server    string        `json:"server"`
+	Version   string        `json:"version"`
+	Transport string        `json:"transport"`
+	Tools     []toolSummary `json:"tools"`
+	// Degraded lists optional modules whose Init failed; their tools are
+	// missing from Tools until a retry succeeds.
+	Degraded []registry.DegradedModule `json:"degraded,omitempty"`
+}
+
+// redactSummaries masks config secrets that a module or plugin copied into
+// a tool description.
+func redactSummaries(cfg *config.Config, summaries []toolSummary) []toolSummary {
+	for i := range summaries {
+		summaries[i].Description = cfg.Redact(summaries[i].Description)
+	}
+	return summaries
+}
+
+// collectToolSummaries lists the tools toolPolicy lets the caller run.
+func collectToolSummaries(modules []types.NexusModule, toolPolicy *policy.Policy) []toolSummary {
+	summaries := []toolSummary{}
+	for _, module := range modules {
+		for _, tool := range moduleTools(module) {
+			decision := toolPolicy.EvaluateTool(module.Name(), tool)
+			if decision == policy.Deny {
+				continue
+			}
+			status := "allowed"
+			if decision == policy.Confirm {
+				status = "confirm"
+			}
+			summaries = append(summaries, toolSummary{
+				Module:      module.Name(),
+				Name:        tool.Name,
+				Description: tool.Description,
+				Status:      status,
+			})
+		}
+	}
+	return summaries
+}
@@ -3,53 +3,89 @@ module github.com/edgeopslabs/nexus
 go 1.25.6
 
 require (
+	github.com/google/cel-go v0.26.1
+	github.com/invopop/jsonschema v0.13.0
 	github.com/mark3labs/mcp-go v0.43.2
+	github.com/prometheus/client_golang v1.23.2
+	go.opentelemetry.io/otel v1.46.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.46.0
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.46.0
+	go.opentelemetry.io/otel/sdk v1.46.0
+	go.opentelemetry.io/otel/trace v1.46.0
 	gopkg.in/yaml.v3 v3.0.1
+	k8s.io/api v0.35.0
 	k8s.io/apimachinery v0.35.0
 	k8s.io/client-go v0.35.0
 )
 
 require (
-	github.com/bahlo/generic-list-go v0.2.0 
-	github.com/buger/jsonparser v1.1.1 
-	github.com/davecgh/go-spew v1.1.1 
-	github.com/emicklei/go-restful/v3 v3.12.2 
-	github.com/fxamacker/cbor/v2 v2.9.0 
-	github.com/go-logr/logr v1.4.3 
-	github.com/go-openapi/jsonpointer v0.21.0 
-	github.com/go-openapi/jsonreference v0.20.2 
-	github.com/go-openapi/swag v0.23.0 
-	github.com/google/gnostic-models v0.7.0 
-	github.com/google/uuid v1.6.0 
-	github.com/invopop/jsonschema v0.13.0 
-	github.com/josharian/intern v1.0.0 
-	github.com/json-iterator/go v1.1.12 
-	github.com/mailru/easyjson v0.7.7 
-	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd 
-	github.com/modern-go/reflect2 v1.0.3-0.20250322232337-35a7c28c31ee 
-	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 
-	github.com/spf13/cast v1.7.1 
-	github.com/spf13/pflag v1.0.9 
-	github.com/wk8/go-ordered-map/v2 v2.1.8 
-	github.com/x448/float16 v0.8.4 
-	github.com/yosida95/uritemplate/v3 v3.0.2 
-	go.yaml.in/yaml/v2 v2.4.3 
-	go.yaml.in/yaml/v3 v3.0.4 
-	golang.org/x/net v0.47.0 
-	golang.org/x/oauth2 v0.30.0 
-	golang.org/x/sys v0.38.0 
-	golang.org/x/term v0.37.0 
-	golang.org/x/text v0.31.0 
-	golang.org/x/time v0.9.0 
-	google.golang.org/protobuf v1.36.8 
-	gopkg.in/evanphx/json-patch.v4 v4.13.0 
-	gopkg.in/inf.v0 v0.9.1 
-	k8s.io/api v0.35.0 
-	k8s.io/klog/v2 v2.130.1 
-	k8s.io/kube-openapi v0.0.0-20250910181357-589584f1c912 
-	k8s.io/utils v0.0.0-20251002143259-bc988d571ff4 
-	sigs.k8s.io/json v0.0.0-20250730193827-2d320260d730 
-	sigs.k8s.io/randfill v1.0.0 
-	sigs.k8s.io/structured-merge-diff/v6 v6.3.0 
-	sigs.k8s.io/yaml v1.6.0 
+	cel.dev/expr v0.25.2 // indirect
+	github.com/antlr4-go/antlr/v4 v4.13.0 // indirect
+	github.com/bahlo/generic-list-go v0.2.0 // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/buger/jsonparser v1.1.1 // indirect
+	github.com/cenkalti/backoff/v5 v5.0.3 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/emicklei/go-restful/v3 v3.12.2 // indirect
+	github.com/fxamacker/cbor/v2 v2.9.0 // indirect
+	github.com/go-logr/logr v1.4.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/go-openapi/jsonpointer v1.0.0 // indirect
+	github.com/go-openapi/jsonreference v1.0.0 // indirect
+	github.com/go-openapi/swag v0.28.0 // indirect
+	github.com/go-openapi/swag/cmdutils v0.28.0 // indirect
+	github.com/go-openapi/swag/conv v0.28.0 // indirect
+	github.com/go-openapi/swag/fileutils v0.28.0 // indirect
+	github.com/go-openapi/swag/jsonutils v0.28.0 // indirect
+	github.com/go-openapi/swag/loading v0.28.0 // indirect
+	github.com/go-openapi/swag/mangling v0.28.0 // indirect
+	github.com/go-openapi/swag/netutils v0.28.0 // indirect
+	github.com/go-openapi/swag/pools v0.28.0 // indirect
+	github.com/go-openapi/swag/stringutils v0.28.0 // indirect
+	github.com/go-openapi/swag/typeutils v0.28.0 // indirect
+	github.com/go-openapi/swag/yamlutils v0.28.0 // indirect
+	github.com/google/gnostic-models v0.7.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.30.0 // indirect
+	github.com/json-iterator/go v1.1.12 // indirect
+	github.com/mailru/easyjson v0.7.7 // indirect
+	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
+	github.com/modern-go/reflect2 v1.0.3-0.20250322232337-35a7c28c31ee // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.2 // indirect
+	github.com/prometheus/common v0.66.1 // indirect
+	github.com/prometheus/procfs v0.16.1 // indirect
+	github.com/spf13/cast v1.7.1 // indirect
+	github.com/spf13/pflag v1.0.9 // indirect
+	github.com/stoewer/go-strcase v1.2.0 // indirect
+	github.com/wk8/go-ordered-map/v2 v2.1.8 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace v1.46.0 // indirect
+	go.opentelemetry.io/otel/metric v1.46.0 // indirect
+	go.opentelemetry.io/proto/otlp v1.11.0 // indirect
+	go.yaml.in/yaml/v2 v2.4.3 // indirect
+	go.yaml.in/yaml/v3 v3.0.5 // indirect
+	golang.org/x/exp v0.0.0-20230515195305-f3d0a9c9a5cc // indirect
+	golang.org/x/net v0.58.0 // indirect
+	golang.org/x/oauth2 v0.36.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/term v0.45.0 // indirect
+	golang.org/x/text v0.41.0 // indirect
+	golang.org/x/time v0.9.0 // indirect
+	google.golang.org/genproto/googleapis/api v0.0.0-20260819154853-08b0e4226688 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260819154853-08b0e4226688 // indirect
+	google.golang.org/grpc v1.83.1 // indirect
+	google.golang.org/protobuf v1.36.12 // indirect
+	gopkg.in/evanphx/json-patch.v4 v4.13.0 // indirect
+	gopkg.in/inf.v0 v0.9.1 // indirect
+	k8s.io/klog/v2 v2.130.1 // indirect
+	k8s.io/kube-openapi v0.0.0-20250910181357-589584f1c912 // indirect
+	k8s.io/utils v0.0.0-20251002143259-bc988d571ff4 // indirect
+	sigs.k8s.io/json v0.0.0-20250730193827-2d320260d730 // indirect
+	sigs.k8s.io/randfill v1.0.0 // indirect
+	sigs.k8s.io/structured-merge-diff/v6 v6.3.0 // indirect
+	sigs.k8s.io/yaml v1.6.0 // indirect
 )
@@ -0,0 +1,168 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+const redacted = "[REDACTED]"
+
+var defaultRedactKeys = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "authorization", "credential"}
+
+type Event struct {
+	Time          time.Time      `json:"time"`
+	Transport     string         `json:"transport"`
+	Session       string         `json:"session,omitempty"`
+	Identity      string         `json:"identity"`
+	Profile       string         `json:"profile,omitempty"` // policy profile; empty for the top-level policy
+	Module        string         `json:"module"`
+	Tool          string         `json:"tool"`
+	Args          map[string]any `json:"args,omitempty"`
+	Decision      string         `json:"decision"`
+	Reason        string         `json:"reason,omitempty"`
+	Confirm       string         `json:"confirm,omitempty"`        // approved, denied
+	ConfirmMethod string         `json:"confirm_method,omitempty"` // tty, elicitation, queue, webhook
+	ConfirmedBy   string         `json:"confirmed_by,omitempty"`
+	DurationMs    int64          `json:"duration_ms"`
+	ResultBytes   int            `json:"result_bytes"`
+	Error         string         `json:"error,omitempty"`
+}
+
+// Logger writes one JSON line per event to every configured sink. A nil
+// *Logger is valid and discards events.
+type Logger struct {
+	mu         sync.Mutex
+	sinks      []io.WriteCloser
+	redactKeys []string
+}
+
+func New(cfg config.AuditConfig) (*Logger, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	logger := &Logger{redactKeys: append(append([]string{}, defaultRedactKeys...), cfg.RedactKeys...)}
+	for i, key := range logger.redactKeys {
+		logger.redactKeys[i] = strings.ToLower(key)
+	}
+
+	if cfg.File != "" {
+		file, err := openRotatingFile(cfg.File, int64(cfg.MaxSizeMB)*1024*1024, cfg.MaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("open audit file: %w", err)
+		}
+		logger.sinks = append(logger.sinks, file)
+	}
+	if cfg.Syslog.Enabled {
+		writer, err := dialSyslog(cfg.Syslog)
+		if err != nil {
+			_ = logger.Close()
+			return nil, fmt.Errorf("connect syslog: %w", err)
+		}
+		logger.sinks = append(logger.sinks, writer)
+	}
+	if len(logger.sinks) == 0 {
+		return nil, fmt.Errorf("audit enabled but neither file nor syslog is configured")
+	}
+	return logger, nil
+}
+
+func (l *Logger) Record(event Event) {
+	if l == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	event.Args = l.Redact(event.Args)
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("failed to encode audit event", "error", err)
+		return
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, sink := range l.sinks {
+		if _, err := sink.Write(line); err != nil {
+			slog.Error("failed to write audit event", "error", err)
+		}
+	}
+}
+
+// Redact returns a copy of args with values of sensitive keys replaced,
+// descending into nested objects and arrays. In name/value pairs such as
+// {"name": "API_TOKEN", "value": "..."}, a sensitive name redacts the value.
+// A nil *Logger uses the built-in keys.
+func (l *Logger) Redact(args map[string]any) map[string]any {
+	if args == nil {
+		return args
+	}
+	secretValue := false
+	if name, ok := args["name"].(string); ok {
+		secretValue = l.sensitive(name)
+	}
+	out := make(map[string]any, len(args))
+	for key, value := range args {
+		if l.sensitive(key) || (secretValue && key == "value") {
+			out[key] = redacted
+			continue
+		}
+		out[key] = l.redactValue(value)
+	}
+	return out
+}
+
+func (l *Logger) redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return l.Redact(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = l.redactValue(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+func (l *Logger) sensitive(key string) bool {
+	lower := strings.ToLower(key)
+	keys := defaultRedactKeys
+	if l != nil {
+		keys = l.redactKeys
+	}
+	for _, needle := range keys {
+		if strings.Contains(lower, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var firstErr error
+	for _, sink := range l.sinks {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	l.sinks = nil
+	return firstErr
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+func TestRecordWritesRedactedJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := New(config.AuditConfig{Enabled: true, File: path, RedactKeys: []string{"namespace"}})
+	if err != nil {
+		t.Fatalf("new audit logger: %v", err)
+	}
+
+	logger.Record(Event{
+		Module:   "kubernetes",
+		Tool:     "k8s_list_pods",
+		Identity: "token:oncall",
+		Decision: "allow",
+		Args: map[string]any{
+			"namespace": "team-a",
+			"name":      "api",
+			"auth":      map[string]any{"api_token": "abc"},
+		},
+	})
+	if err := logger.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open audit file: %v", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		t.Fatalf("expected one audit line")
+	}
+	var event Event
+	if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+		t.Fatalf("decode audit line: %v", err)
+	}
+	if event.Tool != "k8s_list_pods" || event.Time.IsZero() {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if event.Args["namespace"] != redacted {
+		t.Fatalf("expected configured key to be redacted, got %v", event.Args["namespace"])
+	}
+	if event.Args["name"] != "api" {
+		t.Fatalf("expected non-sensitive arg to be kept")
+	}
+	nested, _ := event.Args["auth"].(map[string]any)
+	if nested["api_token"] != redacted {
+		t.Fatalf("expected nested token to be redacted, got %v", nested["api_token"])
+	}
+}
+
+func TestRotatingFileKeepsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	file, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("open rotating file: %v", err)
+	}
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	expect := map[string]string{
+		path:        "dddddddd",
+		path + ".1": "cccccccc",
+		path + ".2": "bbbbbbbb",
+	}
+	for name, want := range expect {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if strings.TrimSpace(string(data)) != want {
+			t.Fatalf("%s: expected %q, got %q", name, want, data)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected at most two backups")
+	}
+}
+
+func TestRotatingFileKeepsWritingWhenRenameFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	// A non-empty directory at the backup name makes the rename fail.
+	if err := os.MkdirAll(filepath.Join(path+".1", "keep"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	file, err := openRotatingFile(path, 10, 1)
+	if err != nil {
+		t.Fatalf("open rotating file: %v", err)
+	}
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatalf("write %q: %v", line, err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if want := "aaaaaaaa\nbbbbbbbb\ncccccccc\n"; string(data) != want {
+		t.Fatalf("expected %q, got %q", want, data)
+	}
+}
+
+func TestNilLoggerDiscards(t *testing.T) {
+	logger, err := New(config.AuditConfig{})
+	if err != nil || logger != nil {
+		t.Fatalf("expected nil logger when disabled")
+	}
+	logger.Record(Event{Tool: "x"})
+	if err := logger.Close(); err != nil {
+		t.Fatalf("close nil logger: %v", err)
+	}
+}
+
+func TestRedactDescendsIntoArrays(t *testing.T) {
+	args := map[string]any{
+		"env": []any{
+			map[string]any{"name": "API_TOKEN", "value": "abc"},
+			map[string]any{"name": "REGION", "value": "eu"},
+			map[string]any{"password": "hunter2"},
+		},
+	}
+	env := (*Logger)(nil).Redact(args)["env"].([]any)
+	if got := env[0].(map[string]any)["value"]; got != redacted {
+		t.Fatalf("expected value of a secret name to be redacted, got %v", got)
+	}
+	if got := env[1].(map[string]any)["value"]; got != "eu" {
+		t.Fatalf("expected value of a plain name to be kept, got %v", got)
+	}
+	if got := env[2].(map[string]any)["password"]; got != redacted {
+		t.Fatalf("expected password in array to be redacted, got %v", got)
+	}
+	if args["env"].([]any)[0].(map[string]any)["value"] != "abc" {
+		t.Fatal("Redact modified its input")
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package audit
+
+import (
+	"io"
+	"log/syslog"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+func dialSyslog(cfg config.AuditSyslogConfig) (io.WriteCloser, error) {
+	tag := cfg.Tag
+	if tag == "" {
+		tag = "nexus-audit"
+	}
+	return syslog.Dial(cfg.Network, cfg.Address, syslog.LOG_INFO|syslog.LOG_AUTH, tag)
+}
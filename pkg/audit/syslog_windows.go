@@ -0,0 +1,14 @@
+//go:build windows
+
+package audit
+
+import (
+	"errors"
+	"io"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+func dialSyslog(config.AuditSyslogConfig) (io.WriteCloser, error) {
+	return nil, errors.New("syslog is not supported on windows")
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+const (
+	MethodToken = "token"
+	MethodJWT   = "jwt"
+	MethodMTLS  = "mtls"
+)
+
+// ErrNoCredentials is returned by an Authenticator when the request carries
+// nothing it understands, so the next authenticator in the chain can try.
+var ErrNoCredentials = errors.New("no credentials")
+
+type Identity struct {
+	Subject string         `json:"subject"`
+	Method  string         `json:"method"`
+	Claims  map[string]any `json:"claims,omitempty"`
+}
+
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+type contextKey struct{}
+
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+func FromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(contextKey{}).(*Identity)
+	return identity
+}
+
+// String returns "method:subject", or "anonymous" when identity is nil.
+func (i *Identity) String() string {
+	if i == nil {
+		return "anonymous"
+	}
+	return i.Method + ":" + i.Subject
+}
+
+// MatchIdentity matches patterns containing ":" against "method:subject"
+// and all others against the subject alone. A nil identity never matches.
+func MatchIdentity(patterns []string, identity *Identity) bool {
+	if identity == nil {
+		return false
+	}
+	for _, pattern := range patterns {
+		value := identity.Subject
+		if strings.Contains(pattern, ":") {
+			value = identity.String()
+		}
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+type Chain []Authenticator
+
+func New(cfg config.AuthConfig) (Chain, error) {
+	var chain Chain
+	for _, method := range cfg.Methods {
+		switch strings.ToLower(method) {
+		case MethodToken:
+			authn, err := NewTokenAuthenticator(cfg.TokensFile)
+			if err != nil {
+				return nil, fmt.Errorf("token auth: %w", err)
+			}
+			chain = append(chain, authn)
+		case MethodJWT:
+			authn, err := NewJWTAuthenticator(cfg.JWT)
+			if err != nil {
+				return nil, fmt.Errorf("jwt auth: %w", err)
+			}
+			chain = append(chain, authn)
+		case MethodMTLS:
+			chain = append(chain, NewMTLSAuthenticator())
+		default:
+			return nil, fmt.Errorf("unknown auth method: %s", method)
+		}
+	}
+	return chain, nil
+}
+
+func (c Chain) Authenticate(r *http.Request) (*Identity, error) {
+	lastErr := ErrNoCredentials
+	for _, authn := range c {
+		identity, err := authn.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			if err != ErrNoCredentials {
+				lastErr = err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return identity, nil
+	}
+	return nil, lastErr
+}
+
+// Middleware rejects unauthenticated requests and stores the identity in the
+// request context. An empty chain lets every request through.
+func Middleware(authn Chain, next http.Handler) http.Handler {
+	if len(authn) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, err := authn.Authenticate(r)
+		if err != nil {
+			slog.Warn("authentication failed", "path", r.URL.Path, "remote", r.RemoteAddr, "error", err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="nexus"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(header[7:])
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func requestWithBearer(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func signJWT(t *testing.T, kid string, secret []byte, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestTokenAuthenticator(t *testing.T) {
+	path := writeFile(t, "tokens", "# agents\noncall:s3cret\n\nide: other-token\n")
+	authn, err := NewTokenAuthenticator(path)
+	if err != nil {
+		t.Fatalf("new token authenticator: %v", err)
+	}
+
+	identity, err := authn.Authenticate(requestWithBearer("other-token"))
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if identity.Subject != "ide" || identity.Method != MethodToken {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+
+	if _, err := authn.Authenticate(requestWithBearer("wrong")); err == nil {
+		t.Fatalf("expected unknown token to be rejected")
+	}
+	if _, err := authn.Authenticate(requestWithBearer("")); err != ErrNoCredentials {
+		t.Fatalf("expected ErrNoCredentials without header, got %v", err)
+	}
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	jwks := `{"keys":[{"kty":"oct","kid":"k1","alg":"HS256","k":"` + base64.RawURLEncoding.EncodeToString(secret) + `"}]}`
+	authn, err := NewJWTAuthenticator(config.JWTAuthConfig{
+		JWKSFile: writeFile(t, "jwks.json", jwks),
+		Issuer:   "https://issuer.example",
+		Audience: "nexus",
+	})
+	if err != nil {
+		t.Fatalf("new jwt authenticator: %v", err)
+	}
+
+	valid := signJWT(t, "k1", secret, map[string]any{
+		"sub": "agent-1",
+		"iss": "https://issuer.example",
+		"aud": []string{"nexus"},
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	identity, err := authn.Authenticate(requestWithBearer(valid))
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if identity.Subject != "agent-1" || identity.Method != MethodJWT {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+
+	expired := signJWT(t, "k1", secret, map[string]any{
+		"sub": "agent-1",
+		"iss": "https://issuer.example",
+		"aud": "nexus",
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	})
+	if _, err := authn.Authenticate(requestWithBearer(expired)); err == nil {
+		t.Fatalf("expected expired token to be rejected")
+	}
+
+	noExpiry := signJWT(t, "k1", secret, map[string]any{"sub": "agent-1", "iss": "https://issuer.example", "aud": "nexus"})
+	if _, err := authn.Authenticate(requestWithBearer(noExpiry)); err == nil {
+		t.Fatalf("expected token without exp to be rejected")
+	}
+
+	forged := signJWT(t, "k1", []byte("wrong-secret"), map[string]any{"sub": "agent-1", "iss": "https://issuer.example", "aud": "nexus", "exp": time.Now().Add(time.Hour).Unix()})
+	if _, err := authn.Authenticate(requestWithBearer(forged)); err == nil {
+		t.Fatalf("expected forged token to be rejected")
+	}
+
+	wrongAudience := signJWT(t, "k1", secret, map[string]any{"sub": "agent-1", "iss": "https://issuer.example", "aud": "other", "exp": time.Now().Add(time.Hour).Unix()})
+	if _, err := authn.Authenticate(requestWithBearer(wrongAudience)); err == nil {
+		t.Fatalf("expected wrong audience to be rejected")
+	}
+}
+
+func TestMTLSAuthenticatorRequiresVerifiedChain(t *testing.T) {
+	authn := NewMTLSAuthenticator()
+	cert := &x509.Certificate{
+		SerialNumber: big.NewInt(7),
+		Subject:      pkix.Name{CommonName: "oncall-agent"},
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/tools", nil)
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	if _, err := authn.Authenticate(r); err != ErrNoCredentials {
+		t.Fatalf("expected unverified certificate to be ignored, got %v", err)
+	}
+
+	r.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+	identity, err := authn.Authenticate(r)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if identity.Subject != "oncall-agent" || identity.Method != MethodMTLS {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	chain, err := New(config.AuthConfig{
+		Methods:    []string{MethodToken},
+		TokensFile: writeFile(t, "tokens", "oncall:s3cret\n"),
+	})
+	if err != nil {
+		t.Fatalf("new chain: %v", err)
+	}
+
+	var seen *Identity
+	handler := Middleware(chain, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = FromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, requestWithBearer(""))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without credentials, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, requestWithBearer("s3cret"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with valid token, got %d", rec.Code)
+	}
+	if seen == nil || seen.Subject != "oncall" {
+		t.Fatalf("expected identity in request context, got %+v", seen)
+	}
+}
+
+func TestNewRejectsUnknownMethod(t *testing.T) {
+	if _, err := New(config.AuthConfig{Methods: []string{"basic"}}); err == nil {
+		t.Fatalf("expected error for unknown auth method")
+	}
+}
+
+func TestMatchIdentity(t *testing.T) {
+	identity := &Identity{Subject: "alice", Method: MethodJWT}
+	for _, tc := range []struct {
+		patterns []string
+		want     bool
+	}{
+		{[]string{"alice"}, true},
+		{[]string{"al*"}, true},
+		{[]string{"jwt:alice"}, true},
+		{[]string{"token:alice"}, false},
+		{[]string{"bob", "*:alice"}, true},
+		{nil, false},
+	} {
+		if got := MatchIdentity(tc.patterns, identity); got != tc.want {
+			t.Fatalf("MatchIdentity(%v): expected %v, got %v", tc.patterns, tc.want, got)
+		}
+	}
+	if MatchIdentity([]string{"*"}, nil) {
+		t.Fatalf("expected a nil identity never to match")
+	}
+}
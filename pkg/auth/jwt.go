@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+const jwtLeeway = 30 * time.Second
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	K   string `json:"k"`
+}
+
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+type hmacKey struct {
+	alg    string
+	secret []byte
+}
+
+type JWTAuthenticator struct {
+	keys         map[string]hmacKey
+	issuer       string
+	audience     string
+	subjectClaim string
+	now          func() time.Time
+}
+
+// NewJWTAuthenticator loads symmetric ("oct") keys from a JWKS file and
+// verifies HS256/HS384/HS512 bearer tokens against them.
+func NewJWTAuthenticator(cfg config.JWTAuthConfig) (*JWTAuthenticator, error) {
+	if cfg.JWKSFile == "" {
+		return nil, fmt.Errorf("jwks_file is required")
+	}
+	data, err := os.ReadFile(cfg.JWKSFile)
+	if err != nil {
+		return nil, err
+	}
+	var set jwkSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", cfg.JWKSFile, err)
+	}
+
+	keys := make(map[string]hmacKey)
+	for _, key := range set.Keys {
+		if key.Kty != "oct" {
+			continue
+		}
+		secret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(key.K, "="))
+		if err != nil {
+			return nil, fmt.Errorf("key %q: invalid k: %w", key.Kid, err)
+		}
+		keys[key.Kid] = hmacKey{alg: key.Alg, secret: secret}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: no oct keys found", cfg.JWKSFile)
+	}
+
+	subjectClaim := cfg.SubjectClaim
+	if subjectClaim == "" {
+		subjectClaim = "sub"
+	}
+	return &JWTAuthenticator{
+		keys:         keys,
+		issuer:       cfg.Issuer,
+		audience:     cfg.Audience,
+		subjectClaim: subjectClaim,
+		now:          time.Now,
+	}, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	token := bearerToken(r)
+	if token == "" || strings.Count(token, ".") != 2 {
+		return nil, ErrNoCredentials
+	}
+	claims, err := a.verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwt: %w", err)
+	}
+	subject, _ := claims[a.subjectClaim].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("invalid jwt: missing %s claim", a.subjectClaim)
+	}
+	return &Identity{Subject: subject, Method: MethodJWT, Claims: claims}, nil
+}
+
+func (a *JWTAuthenticator) verify(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+
+	key, ok := a.keys[header.Kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", header.Kid)
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return nil, fmt.Errorf("algorithm %s not allowed for key %q", header.Alg, header.Kid)
+	}
+	var newHash func() hash.Hash
+	switch header.Alg {
+	case "HS256":
+		newHash = sha256.New
+	case "HS384":
+		newHash = sha512.New384
+	case "HS512":
+		newHash = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+	mac := hmac.New(newHash, key.secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errors.New("signature mismatch")
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	if err := a.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (a *JWTAuthenticator) validateClaims(claims map[string]any) error {
+	now := a.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("token has no exp claim")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(jwtLeeway)) {
+		return errors.New("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok {
+		if now.Add(jwtLeeway).Before(time.Unix(int64(nbf), 0)) {
+			return errors.New("token not yet valid")
+		}
+	}
+	if a.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.issuer {
+			return fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+	if a.audience != "" && !hasAudience(claims["aud"], a.audience) {
+		return fmt.Errorf("audience %q not accepted", a.audience)
+	}
+	return nil
+}
+
+func hasAudience(value any, audience string) bool {
+	switch aud := value.(type) {
+	case string:
+		return aud == audience
+	case []any:
+		for _, item := range aud {
+			if s, ok := item.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, out any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
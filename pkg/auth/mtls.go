@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"net/http"
+)
+
+type MTLSAuthenticator struct{}
+
+func NewMTLSAuthenticator() *MTLSAuthenticator {
+	return &MTLSAuthenticator{}
+}
+
+// Authenticate uses the leaf of the first verified client certificate chain.
+// Unverified peer certificates are ignored, so the server must be configured
+// with a client CA for this to produce identities.
+func (a *MTLSAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, ErrNoCredentials
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	subject := cert.Subject.CommonName
+	if subject == "" && len(cert.DNSNames) > 0 {
+		subject = cert.DNSNames[0]
+	}
+	if subject == "" {
+		subject = cert.Subject.String()
+	}
+	claims := map[string]any{
+		"serial": cert.SerialNumber.String(),
+		"issuer": cert.Issuer.String(),
+	}
+	if len(cert.Subject.Organization) > 0 {
+		claims["organization"] = cert.Subject.Organization
+	}
+	return &Identity{Subject: subject, Method: MethodMTLS, Claims: claims}, nil
+}
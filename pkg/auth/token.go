@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+type tokenEntry struct {
+	subject string
+	digest  [sha256.Size]byte
+}
+
+type TokenAuthenticator struct {
+	tokens []tokenEntry
+}
+
+// NewTokenAuthenticator reads a tokens file with one "subject:token" entry
+// per line. Blank lines and lines starting with '#' are ignored.
+func NewTokenAuthenticator(path string) (*TokenAuthenticator, error) {
+	if path == "" {
+		return nil, fmt.Errorf("tokens_file is required")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	authn := &TokenAuthenticator{}
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		subject, token, ok := strings.Cut(line, ":")
+		subject = strings.TrimSpace(subject)
+		token = strings.TrimSpace(token)
+		if !ok || subject == "" || token == "" {
+			return nil, fmt.Errorf("%s:%d: expected subject:token", path, lineNo)
+		}
+		authn.tokens = append(authn.tokens, tokenEntry{subject: subject, digest: sha256.Sum256([]byte(token))})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(authn.tokens) == 0 {
+		return nil, fmt.Errorf("%s: no tokens defined", path)
+	}
+	return authn, nil
+}
+
+func (a *TokenAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+	digest := sha256.Sum256([]byte(token))
+	var match *tokenEntry
+	for i := range a.tokens {
+		if subtle.ConstantTimeCompare(digest[:], a.tokens[i].digest[:]) == 1 {
+			match = &a.tokens[i]
+		}
+	}
+	if match == nil {
+		// Unknown tokens fall through so a JWT authenticator later in the
+		// chain still gets a chance to verify them.
+		return nil, fmt.Errorf("%w: unknown bearer token", ErrNoCredentials)
+	}
+	return &Identity{Subject: match.subject, Method: MethodToken}, nil
+}
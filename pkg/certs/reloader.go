@@ -0,0 +1,170 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+const defaultReloadInterval = 10 * time.Second
+
+// Reloader serves a certificate (and optional client CA bundle) loaded from
+// disk and swaps it in place when the files change, e.g. when a cert-manager
+// secret volume is updated.
+type Reloader struct {
+	cfg        config.TLSConfig
+	minVersion uint16
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+	digest   [sha256.Size]byte
+}
+
+func NewReloader(cfg config.TLSConfig) (*Reloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, fmt.Errorf("both cert_file and key_file are required")
+	}
+	minVersion, err := ParseVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reloader{cfg: cfg, minVersion: minVersion}
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reloader) TLSConfig() *tls.Config {
+	clientAuth := tls.NoClientCert
+	if r.cfg.ClientCAFile != "" {
+		clientAuth = tls.VerifyClientCertIfGiven
+		if r.cfg.RequireClientCert {
+			clientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	base := &tls.Config{
+		MinVersion: r.minVersion,
+		// http.Server would add these to its own copy of the config only.
+		NextProtos: []string{"h2", "http/1.1"},
+		ClientAuth: clientAuth,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.cert, nil
+		},
+	}
+	if r.cfg.ClientCAFile == "" {
+		return base
+	}
+	// The client CA bundle is reloaded too; hand each handshake the
+	// current pool.
+	template := base.Clone()
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		config := template.Clone()
+		r.mu.RLock()
+		config.ClientCAs = r.clientCA
+		r.mu.RUnlock()
+		return config, nil
+	}
+	return base
+}
+
+// Watch polls the certificate files until ctx is done. Failed reloads keep
+// serving the previous certificate.
+func (r *Reloader) Watch(ctx context.Context) {
+	interval := defaultReloadInterval
+	if r.cfg.ReloadInterval != "" {
+		if parsed, err := time.ParseDuration(r.cfg.ReloadInterval); err == nil && parsed > 0 {
+			interval = parsed
+		} else {
+			slog.Warn("invalid tls reload_interval; using default", "value", r.cfg.ReloadInterval, "default", defaultReloadInterval)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := r.reload()
+			if err != nil {
+				slog.Error("tls certificate reload failed; keeping previous certificate", "error", err)
+				continue
+			}
+			if changed {
+				slog.Info("tls certificate reloaded", "cert", r.cfg.CertFile)
+			}
+		}
+	}
+}
+
+func (r *Reloader) reload() (bool, error) {
+	certPEM, err := os.ReadFile(r.cfg.CertFile)
+	if err != nil {
+		return false, err
+	}
+	keyPEM, err := os.ReadFile(r.cfg.KeyFile)
+	if err != nil {
+		return false, err
+	}
+	var caPEM []byte
+	if r.cfg.ClientCAFile != "" {
+		caPEM, err = os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	digest := sha256.Sum256(bytes.Join([][]byte{certPEM, keyPEM, caPEM}, []byte{0}))
+	r.mu.RLock()
+	unchanged := r.cert != nil && digest == r.digest
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return false, fmt.Errorf("load key pair: %w", err)
+	}
+	var pool *x509.CertPool
+	if caPEM != nil {
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return false, fmt.Errorf("no certificates found in %s", r.cfg.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCA = pool
+	r.digest = digest
+	r.mu.Unlock()
+	return true, nil
+}
+
+func ParseVersion(value string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(value)), "tls") {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported tls min_version: %s (expected 1.2 or 1.3)", value)
+	}
+}
@@ -0,0 +1,145 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+func writeSelfSigned(t *testing.T, dir, commonName string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{commonName},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	certPath := filepath.Join(dir, "tls.crt")
+	keyPath := filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("write cert: %v", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return certPath, keyPath
+}
+
+func servedCommonName(t *testing.T, r *Reloader) string {
+	t.Helper()
+	cfg := r.TLSConfig()
+	if !slices.Contains(cfg.NextProtos, "h2") {
+		t.Fatalf("expected ALPN to offer h2, got %v", cfg.NextProtos)
+	}
+	cert, err := cfg.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("get certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("parse served certificate: %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestReloaderPicksUpRotatedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeSelfSigned(t, dir, "first.example")
+
+	r, err := NewReloader(config.TLSConfig{CertFile: certPath, KeyFile: keyPath, MinVersion: "1.3"})
+	if err != nil {
+		t.Fatalf("new reloader: %v", err)
+	}
+	if got := servedCommonName(t, r); got != "first.example" {
+		t.Fatalf("expected first.example, got %s", got)
+	}
+	if r.TLSConfig().MinVersion != tls.VersionTLS13 {
+		t.Fatalf("expected TLS 1.3 minimum")
+	}
+
+	changed, err := r.reload()
+	if err != nil || changed {
+		t.Fatalf("expected no change on identical files, changed=%t err=%v", changed, err)
+	}
+
+	writeSelfSigned(t, dir, "second.example")
+	changed, err = r.reload()
+	if err != nil || !changed {
+		t.Fatalf("expected reload after rotation, changed=%t err=%v", changed, err)
+	}
+	if got := servedCommonName(t, r); got != "second.example" {
+		t.Fatalf("expected second.example after reload, got %s", got)
+	}
+}
+
+func TestReloaderKeepsPreviousCertificateOnBadFiles(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeSelfSigned(t, dir, "first.example")
+	r, err := NewReloader(config.TLSConfig{CertFile: certPath, KeyFile: keyPath})
+	if err != nil {
+		t.Fatalf("new reloader: %v", err)
+	}
+
+	if err := os.WriteFile(keyPath, []byte("garbage"), 0600); err != nil {
+		t.Fatalf("corrupt key: %v", err)
+	}
+	if _, err := r.reload(); err == nil {
+		t.Fatalf("expected reload error for corrupt key")
+	}
+	if got := servedCommonName(t, r); got != "first.example" {
+		t.Fatalf("expected previous certificate to be kept, got %s", got)
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	if v, err := ParseVersion(""); err != nil || v != tls.VersionTLS12 {
+		t.Fatalf("expected TLS 1.2 default")
+	}
+	for _, version := range []string{"1.0", "1.1", "1.4"} {
+		if _, err := ParseVersion(version); err == nil {
+			t.Fatalf("expected error for unsupported version %s", version)
+		}
+	}
+}
+
+func TestReloaderServesCurrentClientCAs(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeSelfSigned(t, dir, "server.example")
+	r, err := NewReloader(config.TLSConfig{CertFile: certPath, KeyFile: keyPath, ClientCAFile: certPath})
+	if err != nil {
+		t.Fatalf("new reloader: %v", err)
+	}
+	cfg, err := r.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("get config for client: %v", err)
+	}
+	if cfg.ClientCAs == nil || cfg.ClientAuth != tls.VerifyClientCertIfGiven || !slices.Contains(cfg.NextProtos, "h2") {
+		t.Fatalf("expected the handshake config to carry client CAs and ALPN, got %+v", cfg)
+	}
+}
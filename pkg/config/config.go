@@ -1,34 +1,190 @@
 package config
 
-import (
-	"os"
-
-	"gopkg.in/yaml.v3"
-)
+const defaultKubeconfig = "~/.kube/config"
 
 type NexusConfig struct {
 	Server  ServerConfig  `yaml:"server"`
 	Modules ModulesConfig `yaml:"modules"`
 	Policy  PolicyConfig  `yaml:"policy"`
+	Audit   AuditConfig   `yaml:"audit"`
+	Confirm ConfirmConfig `yaml:"confirm"`
+
+	RateLimits []RateLimit     `yaml:"rate_limits"`
+	Execution  ExecutionConfig `yaml:"execution"`
+	Tracing    TracingConfig   `yaml:"tracing"`
+
+	secrets []string          // values resolved from env: and file: references
+	sources map[string]Source // key path -> layer that set it
+	files   []string          // config files merged, in order
+	profile string            // profiles: entry applied
+}
+
+type TracingConfig struct {
+	Enabled     bool              `yaml:"enabled"`
+	Exporter    string            `yaml:"exporter" jsonschema:"enum=otlp,enum=stdout,enum=file"` // otlp, stdout or file
+	Endpoint    string            `yaml:"endpoint"`                                              // OTLP/HTTP host:port, e.g. localhost:4318
+	Insecure    bool              `yaml:"insecure"`                                              // plain HTTP to the collector
+	Headers     map[string]string `yaml:"headers"`
+	File        string            `yaml:"file"`         // for the file exporter
+	SampleRatio float64           `yaml:"sample_ratio"` // 0..1 for root spans; parent decisions are honored
 }
 
 type Config = NexusConfig
 
 type ServerConfig struct {
-	Name     string `yaml:"name"`
-	Version  string `yaml:"version"`
-	LogLevel string `yaml:"log_level"`
-	SafeMode bool   `yaml:"safe_mode"`
+	Name            string        `yaml:"name"`
+	Version         string        `yaml:"version"`
+	LogLevel        string        `yaml:"log_level" jsonschema:"enum=debug,enum=info,enum=warn,enum=warning,enum=error"`
+	SafeMode        bool          `yaml:"safe_mode"`
+	Auth            AuthConfig    `yaml:"auth"`
+	TLS             TLSConfig     `yaml:"tls"`
+	ShutdownTimeout string        `yaml:"shutdown_timeout"` // drain deadline for in-flight calls, e.g. 30s
+	HealthTimeout   string        `yaml:"health_timeout"`   // per-module health check deadline, e.g. 5s
+	ConfigWatch     string        `yaml:"config_watch"`     // poll interval for reloading the config file, e.g. 5s; empty disables
+	Metrics         MetricsConfig `yaml:"metrics"`
+}
+
+type TLSConfig struct {
+	CertFile          string `yaml:"cert_file"`
+	KeyFile           string `yaml:"key_file"`
+	ClientCAFile      string `yaml:"client_ca_file"`
+	RequireClientCert bool   `yaml:"require_client_cert"`
+	MinVersion        string `yaml:"min_version" jsonschema:"enum=1.2,enum=1.3"` // 1.2 or 1.3
+	ReloadInterval    string `yaml:"reload_interval"`                            // e.g. 30s
+}
+
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// MetricsConfig controls the Prometheus /metrics endpoint. It is served
+// unauthenticated, like /healthz, on the SSE/HTTP listener or on Addr.
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Addr    string `yaml:"addr"` // dedicated listener, e.g. :9464; required for stdio
+}
+
+type AuthConfig struct {
+	Methods    []string      `yaml:"methods" jsonschema:"enum=token,enum=jwt,enum=mtls"` // token, jwt, mtls (tried in order)
+	TokensFile string        `yaml:"tokens_file"`
+	JWT        JWTAuthConfig `yaml:"jwt"`
+}
+
+type JWTAuthConfig struct {
+	JWKSFile     string `yaml:"jwks_file"`
+	Issuer       string `yaml:"issuer"`
+	Audience     string `yaml:"audience"`
+	SubjectClaim string `yaml:"subject_claim"`
+}
+
+type AuditConfig struct {
+	Enabled    bool              `yaml:"enabled"`
+	File       string            `yaml:"file"`
+	MaxSizeMB  int               `yaml:"max_size_mb"`
+	MaxBackups int               `yaml:"max_backups"`
+	RedactKeys []string          `yaml:"redact_keys"` // added to the built-in list
+	Syslog     AuditSyslogConfig `yaml:"syslog"`
+}
+
+type ExecutionConfig struct {
+	Timeout           string            `yaml:"timeout"`            // default per-call deadline, e.g. 60s; empty or "0" for none
+	Timeouts          []TimeoutOverride `yaml:"timeouts"`           // first matching entry wins
+	MaxConcurrent     int               `yaml:"max_concurrent"`     // concurrent calls per module; 0 for unlimited
+	ModuleConcurrency map[string]int    `yaml:"module_concurrency"` // per-module override of max_concurrent
+}
+
+type TimeoutOverride struct {
+	Tools   []string `yaml:"tools"` // tool or module/tool globs, e.g. "docker/*"
+	Timeout string   `yaml:"timeout"`
+}
+
+// RateLimit throttles calls to the tools it matches. Every matching entry
+// must have capacity for a call to run.
+type RateLimit struct {
+	Tools       []string `yaml:"tools"`        // tool or module/tool globs, e.g. "kubernetes/*"; empty matches all
+	Identities  []string `yaml:"identities"`   // subject or method:subject globs; empty matches everyone
+	PerIdentity bool     `yaml:"per_identity"` // separate budget per caller instead of one shared budget
+	Rate        string   `yaml:"rate"`         // token bucket refill, e.g. "10/m", "2/s", "100/h"
+	Burst       int      `yaml:"burst"`        // bucket size; defaults to the rate's count
+	DailyQuota  int      `yaml:"daily_quota"`  // calls per UTC day; 0 for none
+}
+
+type ConfirmConfig struct {
+	Methods []string             `yaml:"methods" jsonschema:"enum=tty,enum=elicitation,enum=queue,enum=webhook"` // tried in order: tty, elicitation, queue, webhook
+	Timeout string               `yaml:"timeout"`                                                                // how long to wait for a decision, e.g. 2m
+	Queue   ConfirmQueueConfig   `yaml:"queue"`
+	Webhook ConfirmWebhookConfig `yaml:"webhook"`
+}
+
+type ConfirmQueueConfig struct {
+	Addr      string   `yaml:"addr"`      // dedicated listener; required for stdio and when server.auth is off
+	Approvers []string `yaml:"approvers"` // subject or method:subject globs allowed to list and decide; empty allows any caller
+}
+
+type ConfirmWebhookConfig struct {
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+type AuditSyslogConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Network string `yaml:"network"` // "" for the local daemon, or udp/tcp
+	Address string `yaml:"address"`
+	Tag     string `yaml:"tag"`
 }
 
 type PolicyConfig struct {
-	AllowModules []string `yaml:"allow_modules"`
-	DenyModules  []string `yaml:"deny_modules"`
-	AllowTools   []string `yaml:"allow_tools"`
-	DenyTools    []string `yaml:"deny_tools"`
-	ConfirmTools []string `yaml:"confirm_tools"`
+	AllowModules []string  `yaml:"allow_modules"`
+	DenyModules  []string  `yaml:"deny_modules"`
+	AllowTools   []string  `yaml:"allow_tools"`
+	DenyTools    []string  `yaml:"deny_tools"`
+	ConfirmTools []string  `yaml:"confirm_tools"`
+	ArgRules     []ArgRule `yaml:"arg_rules"`
+	Rules        []Rule    `yaml:"rules"`
+
+	Profiles map[string]PolicyProfile `yaml:"profiles"`
+}
+
+// PolicyProfile is a complete policy used instead of the top-level one for
+// the identities it is bound to, or for stdio launches with --profile.
+type PolicyProfile struct {
+	Identities   []string `yaml:"identities"` // subject or method:subject globs, e.g. "oncall", "jwt:*@ops.example.com"
+	SafeMode     *bool    `yaml:"safe_mode"`  // overrides server.safe_mode
+	PolicyConfig `yaml:",inline"`
+}
+
+// Rule is a CEL expression evaluated per call over module, tool, args,
+// identity, time and annotations. The first rule whose expression is true
+// and whose effect is at least as strict as the name lists and arg_rules
+// decision (deny > confirm > allow) decides the call: rules only tighten.
+type Rule struct {
+	Name       string `yaml:"name"`
+	Expression string `yaml:"expression"`
+	Effect     string `yaml:"effect" jsonschema:"required,enum=allow,enum=deny,enum=confirm"` // allow, deny, confirm
+	Reason     string `yaml:"reason"`
+}
+
+// ArgRule constrains calls to matching tools by their arguments. An "allow"
+// rule denies calls whose arguments do not match; "deny" and "confirm" rules
+// apply only when they do.
+type ArgRule struct {
+	Tools  []string              `yaml:"tools"`
+	Effect string                `yaml:"effect" jsonschema:"required,enum=allow,enum=deny,enum=confirm"` // allow, deny, confirm
+	Args   map[string]ArgMatcher `yaml:"args"`
 }
 
+// ArgMatcher matches a single argument value. Every field that is set must
+// match; a missing argument never matches.
+type ArgMatcher struct {
+	Glob      string   `yaml:"glob"`
+	Regex     string   `yaml:"regex"`
+	Enum      []string `yaml:"enum"`
+	CleanPath bool     `yaml:"clean_path"` // path.Clean the value before matching; implied by a glob or regex starting with "/"
+}
+
+// ModulesConfig holds one section per module. A module whose section sets
+// required: true stops startup when its Init fails; any other module is
+// marked degraded instead and its Init retried in the background.
 type ModulesConfig struct {
 	Kubernetes KubernetesConfig `yaml:"kubernetes"`
 	AWS        AWSConfig        `yaml:"aws"`
@@ -39,22 +195,70 @@ type ModulesConfig struct {
 }
 
 type KubernetesConfig struct {
-	Enabled    bool   `yaml:"enabled"`
-	Kubeconfig string `yaml:"kubeconfig"`
+	Enabled    bool                          `yaml:"enabled"`
+	Required   bool                          `yaml:"required"`
+	Kubeconfig string                        `yaml:"kubeconfig"`
+	Context    string                        `yaml:"context"`   // default context; empty uses the kubeconfig's current-context
+	Contexts   []string                      `yaml:"contexts"`  // further contexts a call may pick with the context argument
+	Instances  map[string]KubernetesInstance `yaml:"instances"` // named clusters; each becomes module kubernetes@<name>
+}
+
+// KubernetesInstance overrides the kubernetes section for one instance;
+// empty fields inherit it.
+type KubernetesInstance struct {
+	Kubeconfig string   `yaml:"kubeconfig"`
+	Context    string   `yaml:"context"`
+	Contexts   []string `yaml:"contexts"`
+}
+
+// Instance returns the settings of the named instance.
+func (c KubernetesConfig) Instance(name string) KubernetesConfig {
+	if instance, ok := c.Instances[name]; ok {
+		if instance.Kubeconfig != "" {
+			c.Kubeconfig = instance.Kubeconfig
+		}
+		if instance.Context != "" {
+			c.Context = instance.Context
+		}
+		if instance.Contexts != nil {
+			c.Contexts = instance.Contexts
+		}
+	}
+	c.Instances = nil
+	return c
 }
 
 type AWSConfig struct {
-	Enabled bool   `yaml:"enabled"`
-	Region  string `yaml:"region"`
+	Enabled  bool   `yaml:"enabled"`
+	Required bool   `yaml:"required"`
+	Region   string `yaml:"region"`
 }
 
 type PrometheusConfig struct {
-	Enabled bool   `yaml:"enabled"`
-	URL     string `yaml:"url"`
+	Enabled   bool                          `yaml:"enabled"`
+	Required  bool                          `yaml:"required"`
+	URL       string                        `yaml:"url"`
+	Instances map[string]PrometheusInstance `yaml:"instances"` // named servers; each becomes module prometheus@<name>
+}
+
+// PrometheusInstance overrides the prometheus section for one instance;
+// empty fields inherit it.
+type PrometheusInstance struct {
+	URL string `yaml:"url"`
+}
+
+// Instance returns the settings of the named instance.
+func (c PrometheusConfig) Instance(name string) PrometheusConfig {
+	if instance, ok := c.Instances[name]; ok && instance.URL != "" {
+		c.URL = instance.URL
+	}
+	c.Instances = nil
+	return c
 }
 
 type LogsConfig struct {
 	Enabled    bool     `yaml:"enabled"`
+	Required   bool     `yaml:"required"`
 	AllowPaths []string `yaml:"allow_paths"`
 	MaxBytes   int      `yaml:"max_bytes"`
 	MaxLines   int      `yaml:"max_lines"`
@@ -62,12 +266,14 @@ type LogsConfig struct {
 
 type DockerConfig struct {
 	Enabled  bool   `yaml:"enabled"`
+	Required bool   `yaml:"required"`
 	CLI      string `yaml:"cli"`
 	MaxLines int    `yaml:"max_lines"`
 }
 
 type PluginsConfig struct {
 	Enabled  bool     `yaml:"enabled"`
+	Required bool     `yaml:"required"`
 	Dir      string   `yaml:"dir"`
 	MaxBytes int      `yaml:"max_bytes"`
 	Env      []string `yaml:"env"`
@@ -76,15 +282,26 @@ type PluginsConfig struct {
 func DefaultConfig() *NexusConfig {
 	return &NexusConfig{
 		Server: ServerConfig{
-			Name:     "Nexus",
-			Version:  "v0.0.1",
-			LogLevel: "info",
-			SafeMode: true,
+			Name:            "Nexus",
+			Version:         "v0.0.1",
+			LogLevel:        "info",
+			SafeMode:        true,
+			ShutdownTimeout: "30s",
+			HealthTimeout:   "5s",
+		},
+		Confirm: ConfirmConfig{
+			Methods: []string{"tty"},
+			Timeout: "2m",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "otlp",
+			Endpoint:    "localhost:4318",
+			SampleRatio: 1,
 		},
 		Modules: ModulesConfig{
 			Kubernetes: KubernetesConfig{
 				Enabled:    true,
-				Kubeconfig: "~/.kube/config",
+				Kubeconfig: defaultKubeconfig,
 			},
 			AWS: AWSConfig{
 				Enabled: false,
@@ -115,19 +332,14 @@ func DefaultConfig() *NexusConfig {
 	}
 }
 
+// LoadConfig reads the config file and the layers Load merges into it,
+// resolves ${ENV} interpolation and env: and file: secret references, and
+// validates the result. A missing or unparsable file yields the defaults and
+// the error. Unknown keys, type mismatches and semantic problems yield the
+// decoded config and a *ValidationError, so callers can choose to warn or to
+// refuse.
 func LoadConfig(path string) (*NexusConfig, error) {
-	cfg := DefaultConfig()
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return cfg, err
-	}
-
-	if err := yaml.Unmarshal(data, cfg); err != nil {
-		return cfg, err
-	}
-
-	applyDefaults(cfg)
-	return cfg, nil
+	return Load(path, LoadOptions{})
 }
 
 func applyDefaults(cfg *NexusConfig) {
@@ -140,8 +352,17 @@ func applyDefaults(cfg *NexusConfig) {
 	if cfg.Server.LogLevel == "" {
 		cfg.Server.LogLevel = "info"
 	}
+	if cfg.Server.ShutdownTimeout == "" {
+		cfg.Server.ShutdownTimeout = "30s"
+	}
+	if len(cfg.Confirm.Methods) == 0 {
+		cfg.Confirm.Methods = []string{"tty"}
+	}
+	if cfg.Confirm.Timeout == "" {
+		cfg.Confirm.Timeout = "2m"
+	}
 	if cfg.Modules.Kubernetes.Kubeconfig == "" {
-		cfg.Modules.Kubernetes.Kubeconfig = "~/.kube/config"
+		cfg.Modules.Kubernetes.Kubeconfig = defaultKubeconfig
 	}
 	if cfg.Modules.AWS.Region == "" {
 		cfg.Modules.AWS.Region = "us-east-1"
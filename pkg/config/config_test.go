@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -39,3 +41,323 @@ func TestLoadConfigMissingFileReturnsDefaults(t *testing.T) {
 		t.Fatalf("expected default config returned on error")
 	}
 }
+
+func TestLoadConfigReportsUnknownKeysWithPosition(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nexus.yaml")
+	data := "policy:\n  allow_tool:\n    - \"k8s_*\"\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	_, err := LoadConfig(path)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || len(invalid.Problems) != 1 {
+		t.Fatalf("expected one validation problem, got %v", err)
+	}
+	p := invalid.Problems[0]
+	if p.Path != "policy.allow_tool" || p.Line != 2 || p.Column != 3 {
+		t.Fatalf("unexpected problem location: %+v", p)
+	}
+	if !strings.Contains(p.Message, `did you mean "allow_tools"`) {
+		t.Fatalf("expected a suggestion, got %q", p.Message)
+	}
+}
+
+func TestLoadConfigReportsSemanticProblems(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nexus.yaml")
+	data := `server:
+  shutdown_timeout: "30"
+policy:
+  deny_tools: ["[bad"]
+modules:
+  prometheus:
+    url: "localhost:9090"
+  logs:
+    enabled: true
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if !cfg.Modules.Logs.Enabled {
+		t.Fatalf("expected the decoded config alongside validation problems")
+	}
+	got := map[string]int{}
+	for _, p := range invalid.Problems {
+		got[p.Path] = p.Line
+	}
+	want := map[string]int{
+		"server.shutdown_timeout":  2,
+		"policy.deny_tools[0]":     4,
+		"modules.prometheus.url":   7,
+		"modules.logs.allow_paths": 8,
+	}
+	for key, line := range want {
+		if got[key] != line {
+			t.Fatalf("expected %s at line %d, got problems %v", key, line, invalid.Problems)
+		}
+	}
+}
+
+func TestValidateSkipsPathsOfDisabledLogsModule(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Modules.Kubernetes.Enabled = false
+	cfg.Modules.Logs.Enabled = false
+	cfg.Modules.Logs.AllowPaths = []string{filepath.Join(t.TempDir(), "gone")}
+	for _, p := range Validate(cfg) {
+		if strings.HasPrefix(p.Path, "modules.logs") {
+			t.Fatalf("expected no logs problems while the module is disabled, got %v", p)
+		}
+	}
+
+	cfg.Modules.Logs.Enabled = true
+	found := false
+	for _, p := range Validate(cfg) {
+		found = found || p.Path == "modules.logs.allow_paths[0]"
+	}
+	if !found {
+		t.Fatal("expected a missing allow_paths entry to be reported once logs are enabled")
+	}
+}
+
+func TestLoadConfigResolvesReferences(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "webhook-token")
+	if err := os.WriteFile(secretFile, []byte("s3cr3t-file\n"), 0600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+	t.Setenv("NEXUS_TEST_PROM_HOST", "prom.internal")
+	t.Setenv("NEXUS_TEST_SAFE", "false")
+	t.Setenv("NEXUS_TEST_TOKEN", "s3cr3t-env")
+
+	path := filepath.Join(dir, "nexus.yaml")
+	data := `server:
+  safe_mode: ${NEXUS_TEST_SAFE}
+  version: "${NEXUS_TEST_UNSET:-v9}"
+modules:
+  prometheus:
+    url: "http://${NEXUS_TEST_PROM_HOST}:9090"
+confirm:
+  webhook:
+    url: "https://hooks.example.com/$$literal"
+    headers:
+      Authorization: "env:NEXUS_TEST_TOKEN"
+      X-Token: "file:` + secretFile + `"
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.Server.SafeMode || cfg.Server.Version != "v9" {
+		t.Fatalf("expected interpolated server settings, got %+v", cfg.Server)
+	}
+	if cfg.Modules.Prometheus.URL != "http://prom.internal:9090" {
+		t.Fatalf("unexpected prometheus url %q", cfg.Modules.Prometheus.URL)
+	}
+	if cfg.Confirm.Webhook.URL != "https://hooks.example.com/$literal" {
+		t.Fatalf("expected $$ to be a literal $, got %q", cfg.Confirm.Webhook.URL)
+	}
+	headers := cfg.Confirm.Webhook.Headers
+	if headers["Authorization"] != "s3cr3t-env" || headers["X-Token"] != "s3cr3t-file" {
+		t.Fatalf("expected secret references resolved, got %v", headers)
+	}
+	if got := cfg.Redact("token s3cr3t-env and s3cr3t-file at prom.internal"); got != "token [REDACTED] and [REDACTED] at prom.internal" {
+		t.Fatalf("unexpected redaction %q", got)
+	}
+}
+
+func TestRedactSkipsShortSecrets(t *testing.T) {
+	t.Setenv("NEXUS_TEST_SHORT", "1")
+	t.Setenv("NEXUS_TEST_TOKEN", "s3cr3t")
+	path := filepath.Join(t.TempDir(), "nexus.yaml")
+	data := "confirm:\n  webhook:\n    headers:\n      X-Debug: \"env:NEXUS_TEST_SHORT\"\n      X-Token: \"env:NEXUS_TEST_TOKEN\"\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if got := cfg.Redact("retry 1 of 3 with s3cr3t"); got != "retry 1 of 3 with [REDACTED]" {
+		t.Fatalf("unexpected redaction %q", got)
+	}
+}
+
+func TestLoadConfigReportsUnresolvedReferences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nexus.yaml")
+	data := "modules:\n  prometheus:\n    url: \"http://${NEXUS_TEST_MISSING}:9090\"\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	_, err := LoadConfig(path)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	p := invalid.Problems[0]
+	if p.Path != "modules.prometheus.url" || p.Line != 3 || !strings.Contains(p.Message, "NEXUS_TEST_MISSING") {
+		t.Fatalf("unexpected problem %+v", p)
+	}
+}
+
+func TestLoadMergesLayersInOrder(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		return path
+	}
+	path := write("nexus.yaml", `include: ["extra.yaml"]
+server:
+  log_level: info
+  name: base
+modules:
+  prometheus:
+    enabled: true
+    url: "http://base:9090"
+profiles:
+  prod:
+    modules:
+      prometheus:
+        url: "http://prod:9090"
+`)
+	extra := write("extra.yaml", "server:\n  name: extra\n  log_level: warn\n")
+	dropIn := write("nexus.d/10-debug.yaml", "server:\n  log_level: debug\n")
+	t.Setenv("NEXUS_EXECUTION_MODULE_CONCURRENCY_KUBERNETES", "2")
+	t.Setenv("NEXUS_POLICY_DENY_TOOLS", "k8s_delete*, docker_*")
+
+	cfg, err := Load(path, LoadOptions{Profile: "prod"})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Server.Name != "extra" || cfg.Server.LogLevel != "debug" {
+		t.Fatalf("expected includes then conf.d to override the main file, got %+v", cfg.Server)
+	}
+	if !cfg.Modules.Prometheus.Enabled || cfg.Modules.Prometheus.URL != "http://prod:9090" {
+		t.Fatalf("expected profile overlay merged into prometheus, got %+v", cfg.Modules.Prometheus)
+	}
+	if cfg.Execution.ModuleConcurrency["kubernetes"] != 2 {
+		t.Fatalf("expected env override of a map entry, got %v", cfg.Execution.ModuleConcurrency)
+	}
+	if got := cfg.Policy.DenyTools; len(got) != 2 || got[1] != "docker_*" {
+		t.Fatalf("expected comma-separated env list, got %v", got)
+	}
+
+	sources := map[string]string{
+		"server.name":                             extra + ":2",
+		"server.log_level":                        dropIn + ":2",
+		"modules.prometheus.url":                  path + ":13 (profile prod)",
+		"modules.prometheus.enabled":              path + ":7",
+		"execution.module_concurrency.kubernetes": "env NEXUS_EXECUTION_MODULE_CONCURRENCY_KUBERNETES",
+		"policy.deny_tools[0]":                    "env NEXUS_POLICY_DENY_TOOLS",
+		"server.version":                          "default",
+	}
+	for key, want := range sources {
+		if got := cfg.Source(key).String(); got != want {
+			t.Fatalf("source of %s: expected %q, got %q", key, want, got)
+		}
+	}
+	if files := cfg.Files(); len(files) != 3 || files[2] != dropIn {
+		t.Fatalf("unexpected files %v", files)
+	}
+
+	unprofiled, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if unprofiled.Modules.Prometheus.URL != "http://base:9090" || unprofiled.Profile() != "" {
+		t.Fatalf("expected no profile without --profile, got %q", unprofiled.Modules.Prometheus.URL)
+	}
+}
+
+func TestLoadChecksEveryLayerAgainstSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nexus.yaml")
+	data := `server:
+  log_level: "${NEXUS_TEST_LEVEL}"
+profiles:
+  prod:
+    tracing:
+      exporter: zipkin
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("NEXUS_TEST_LEVEL", "DEBUG")
+	t.Setenv("NEXUS_CONFIRM_METHODS", "tty,pager")
+
+	_, err := Load(path, LoadOptions{})
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected validation problems, got %v", err)
+	}
+	var got []string
+	for _, p := range invalid.Problems {
+		got = append(got, p.String())
+	}
+	want := []string{
+		path + `:6:17: profiles.prod.tracing.exporter: unknown value "zipkin"; expected one of otlp, stdout, file`,
+		`env NEXUS_CONFIRM_METHODS: confirm.methods[1]: unknown value "pager"; expected one of tty, elicitation, queue, webhook`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected problems:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestSchemaDescribesLayersAndProfiles(t *testing.T) {
+	s := Schema()
+	for _, key := range []string{"server", "modules", "include", "profiles"} {
+		if _, ok := s.Properties.Get(key); !ok {
+			t.Fatalf("schema lacks %q", key)
+		}
+	}
+	profiles, _ := s.Properties.Get("profiles")
+	if _, ok := profiles.AdditionalProperties.Properties.Get("server"); !ok {
+		t.Fatalf("profile overlays should accept config keys")
+	}
+}
+
+func TestKubernetesInstancesInheritContexts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nexus.yaml")
+	data := `modules:
+  kubernetes:
+    context: prod
+    contexts: [staging, staging]
+    instances:
+      eu: {}
+      us: { context: us-prod, contexts: [us-staging] }
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || len(invalid.Problems) != 1 || invalid.Problems[0].Path != "modules.kubernetes.contexts[1]" {
+		t.Fatalf("expected the duplicate context to be reported, got %v", err)
+	}
+	eu := cfg.Modules.Kubernetes.Instance("eu")
+	if eu.Context != "prod" || strings.Join(eu.Contexts, ",") != "staging,staging" {
+		t.Fatalf("expected eu to inherit the section's contexts, got %+v", eu)
+	}
+	us := cfg.Modules.Kubernetes.Instance("us")
+	if us.Context != "us-prod" || strings.Join(us.Contexts, ",") != "us-staging" {
+		t.Fatalf("expected us to override the contexts, got %+v", us)
+	}
+}
@@ -0,0 +1,506 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/edgeopslabs/nexus/pkg/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix marks environment variables that override config keys, e.g.
+// NEXUS_MODULES_PROMETHEUS_URL for modules.prometheus.url.
+const EnvPrefix = "NEXUS_"
+
+// LoadOptions selects the layers Load merges on top of the main file.
+type LoadOptions struct {
+	Profile string // entry of the top-level profiles map; unknown names are ignored
+}
+
+// Source says where a config value came from.
+type Source struct {
+	File    string // empty for defaults and env overrides
+	Line    int
+	Column  int
+	Profile string // profile overlay that set the value
+	Env     string // environment variable that set the value
+}
+
+func (s Source) String() string {
+	switch {
+	case s.Env != "":
+		return "env " + s.Env
+	case s.File == "":
+		return "default"
+	case s.Profile != "":
+		return fmt.Sprintf("%s:%d (profile %s)", s.File, s.Line, s.Profile)
+	default:
+		return fmt.Sprintf("%s:%d", s.File, s.Line)
+	}
+}
+
+// layer is one YAML document merged into the config: the main file, an
+// included or conf.d file, a profile overlay or the env overrides.
+type layer struct {
+	doc     *yaml.Node
+	file    string
+	profile string
+	env     map[string]string // key path -> variable, for the env layer
+	prefix  string            // key path of the document within its file
+}
+
+func (l layer) source(p string, node *yaml.Node) Source {
+	if l.env != nil {
+		for ; p != ""; p = parentPath(p) {
+			if name, ok := l.env[p]; ok {
+				return Source{Env: name}
+			}
+		}
+		return Source{}
+	}
+	return Source{File: l.file, Line: node.Line, Column: node.Column, Profile: l.profile}
+}
+
+// Load reads the main config file and merges, in order: the files listed
+// under include: (paths relative to the main file, globs allowed), the
+// <name>.d/*.yaml files next to it, the selected entry of profiles:, and
+// NEXUS_* environment overrides. Mappings merge key by key; any other value
+// replaces what earlier layers set. Errors follow LoadConfig.
+func Load(path string, opts LoadOptions) (*NexusConfig, error) {
+	cfg := DefaultConfig()
+	main, err := readLayer(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	var problems []Problem
+	includes, profiles, metaProblems := splitMeta(main)
+	problems = append(problems, metaProblems...)
+	files, fileProblems := layerFiles(path, includes)
+	problems = append(problems, fileProblems...)
+
+	layers := []layer{main}
+	for _, file := range files {
+		extra, err := readLayer(file)
+		if err != nil {
+			return DefaultConfig(), fmt.Errorf("%s: %w", file, err)
+		}
+		for _, key := range []string{"include", "profiles"} {
+			if node := mappingValue(extra.doc, key); node != nil {
+				problems = append(problems, Problem{File: file, Path: key, Line: node.Line, Column: node.Column, Message: "only allowed in the main config file"})
+			}
+		}
+		removeKeys(extra.doc, "include", "profiles")
+		layers = append(layers, extra)
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		overlay := layer{doc: profiles[name], file: path, profile: name, prefix: "profiles." + name}
+		if name == opts.Profile {
+			layers = append(layers, overlay)
+			cfg.profile = name
+			continue
+		}
+		problems = append(problems, checkLayer(overlay, nil)...)
+	}
+	layers = append(layers, envLayer(os.Environ()))
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	sources := make(map[string]Source)
+	for _, l := range layers {
+		var secrets []string
+		problems = append(problems, checkLayer(l, &secrets)...)
+		cfg.secrets = append(cfg.secrets, secrets...)
+		mergeNode(merged, l.doc, "", l, sources)
+		if l.file != "" && l.profile == "" {
+			cfg.files = append(cfg.files, l.file)
+		}
+	}
+	// Type mismatches were reported per layer; decode what remains.
+	_ = merged.Decode(cfg)
+	cfg.sources = sources
+
+	applyDefaults(cfg)
+	semantic := Validate(cfg)
+	locate(semantic, sources)
+	problems = append(problems, semantic...)
+	for i := range problems {
+		problems[i].Message = cfg.Redact(problems[i].Message)
+	}
+	if len(problems) > 0 {
+		return cfg, &ValidationError{File: path, Problems: problems}
+	}
+	return cfg, nil
+}
+
+// Files lists the config files Load would read for path, in merge order.
+func Files(path string) []string {
+	main, err := readLayer(path)
+	if err != nil {
+		return []string{path}
+	}
+	includes, _, _ := splitMeta(main)
+	files, _ := layerFiles(path, includes)
+	return append([]string{path}, files...)
+}
+
+func readLayer(path string) (layer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return layer{}, err
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return layer{}, err
+	}
+	doc := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if len(root.Content) > 0 && root.Content[0].Kind == yaml.MappingNode {
+		doc = root.Content[0]
+	} else if len(root.Content) > 0 && root.Content[0].Tag != "!!null" {
+		return layer{}, fmt.Errorf("line %d: expected a mapping at the top level", root.Content[0].Line)
+	}
+	return layer{doc: doc, file: path}, nil
+}
+
+// checkLayer resolves references in one layer in place, then reports
+// unresolvable references, schema violations and type mismatches. Without
+// secrets the layer is an unselected profile: its references may name
+// variables this host does not set, so only the schema is checked.
+func checkLayer(l layer, secrets *[]string) []Problem {
+	refProblems, resolved := resolveReferences(l.doc, l.prefix)
+	var problems []Problem
+	if secrets != nil {
+		problems = refProblems
+		*secrets = keepSecrets(resolved)
+	}
+	reported := make(map[string]bool)
+	for _, issue := range schema.Validate(layerSchema(), l.doc) {
+		p := l.prefix
+		if issue.Path != "" {
+			p = joinPath(l.prefix, issue.Path)
+		}
+		reported[p] = true
+		problems = append(problems, Problem{Path: p, Line: issue.Line, Column: issue.Column, Message: issue.Message})
+	}
+	if secrets != nil {
+		var scratch NexusConfig
+		if err := l.doc.Decode(&scratch); err != nil {
+			if typeErr, ok := err.(*yaml.TypeError); ok {
+				for _, msg := range typeErr.Errors {
+					// A value of the wrong kind was already reported by the schema.
+					if problem := typeProblem(msg, l.doc, l.prefix); !reported[problem.Path] {
+						problems = append(problems, problem)
+					}
+				}
+			}
+		}
+	}
+	for i := range problems {
+		problems[i].File = l.file
+		if l.env != nil {
+			problems[i].File = l.source(problems[i].Path, nil).String()
+			problems[i].Line, problems[i].Column = 0, 0
+		}
+	}
+	return problems
+}
+
+// splitMeta removes include: and profiles: from the main file's document.
+func splitMeta(l layer) ([]string, map[string]*yaml.Node, []Problem) {
+	var problems []Problem
+	var includes []string
+	if node := mappingValue(l.doc, "include"); node != nil {
+		if err := node.Decode(&includes); err != nil {
+			problems = append(problems, Problem{File: l.file, Path: "include", Line: node.Line, Column: node.Column, Message: "must be a list of paths"})
+		}
+	}
+	profiles := make(map[string]*yaml.Node)
+	if node := mappingValue(l.doc, "profiles"); node != nil {
+		if node.Kind != yaml.MappingNode {
+			problems = append(problems, Problem{File: l.file, Path: "profiles", Line: node.Line, Column: node.Column, Message: "must map profile names to config overlays"})
+		} else {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				name, overlay := node.Content[i], node.Content[i+1]
+				if overlay.Kind != yaml.MappingNode {
+					problems = append(problems, Problem{File: l.file, Path: "profiles." + name.Value, Line: overlay.Line, Column: overlay.Column, Message: "must be a mapping"})
+					continue
+				}
+				profiles[name.Value] = overlay
+			}
+		}
+	}
+	removeKeys(l.doc, "include", "profiles")
+	return includes, profiles, problems
+}
+
+// layerFiles resolves include: entries against the main file's directory,
+// then appends <name>.d/*.yaml; globs are expanded in lexical order.
+func layerFiles(path string, includes []string) ([]string, []Problem) {
+	dir := filepath.Dir(path)
+	var files []string
+	var problems []Problem
+	for i, include := range includes {
+		pattern := ExpandHome(include)
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			problems = append(problems, Problem{File: path, Path: fmt.Sprintf("include[%d]", i), Message: fmt.Sprintf("invalid pattern %q: %v", include, err)})
+			continue
+		}
+		if len(matches) == 0 && !strings.ContainsAny(include, "*?[") {
+			problems = append(problems, Problem{File: path, Path: fmt.Sprintf("include[%d]", i), Message: fmt.Sprintf("%s: no such file", include)})
+		}
+		files = append(files, matches...)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	confDir := filepath.Join(dir, base+".d")
+	var dropIns []string
+	for _, ext := range []string{"*.yaml", "*.yml"} {
+		matches, _ := filepath.Glob(filepath.Join(confDir, ext))
+		dropIns = append(dropIns, matches...)
+	}
+	sort.Strings(dropIns)
+	return append(files, dropIns...), problems
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func removeKeys(node *yaml.Node, keys ...string) {
+	content := node.Content[:0]
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		remove := false
+		for _, key := range keys {
+			remove = remove || node.Content[i].Value == key
+		}
+		if !remove {
+			content = append(content, node.Content[i], node.Content[i+1])
+		}
+	}
+	node.Content = content
+}
+
+// mergeNode merges the mapping src into dst and records the source of each
+// value it sets.
+func mergeNode(dst, src *yaml.Node, prefix string, l layer, sources map[string]Source) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		keyPath := joinPath(prefix, key.Value)
+		existing := -1
+		for j := 0; j+1 < len(dst.Content); j += 2 {
+			if dst.Content[j].Value == key.Value {
+				existing = j
+				break
+			}
+		}
+		if existing >= 0 && dst.Content[existing+1].Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			sources[keyPath] = l.source(keyPath, key)
+			mergeNode(dst.Content[existing+1], value, keyPath, l, sources)
+			continue
+		}
+		if existing >= 0 {
+			dst.Content[existing+1] = value
+		} else {
+			dst.Content = append(dst.Content, key, value)
+		}
+		for p := range sources {
+			if p == keyPath || strings.HasPrefix(p, keyPath+".") || strings.HasPrefix(p, keyPath+"[") {
+				delete(sources, p)
+			}
+		}
+		recordSources(value, keyPath, position(key, value), l, sources)
+	}
+}
+
+func recordSources(node *yaml.Node, p string, at *yaml.Node, l layer, sources map[string]Source) {
+	sources[p] = l.source(p, at)
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			recordSources(value, joinPath(p, key.Value), position(key, value), l, sources)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			recordSources(item, fmt.Sprintf("%s[%d]", p, i), item, l, sources)
+		}
+	}
+}
+
+// envLayer turns NEXUS_* variables into an overlay. Each variable names a
+// key path with "_" between and within key names; the first reading that
+// matches a config key wins. Values are YAML scalars; lists also accept
+// comma-separated items. Variables that match no key are ignored.
+func envLayer(environ []string) layer {
+	l := layer{doc: &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, env: make(map[string]string)}
+	sort.Strings(environ)
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		rest, ok := strings.CutPrefix(name, EnvPrefix)
+		if !ok || rest == "" {
+			continue
+		}
+		keys, t, ok := envPath(reflect.TypeOf(NexusConfig{}), strings.Split(strings.ToLower(rest), "_"))
+		if !ok {
+			continue
+		}
+		node := envValue(value, t)
+		setLine(node, len(l.env)+1)
+		parent := l.doc
+		for _, key := range keys[:len(keys)-1] {
+			child := mappingValue(parent, key)
+			if child == nil || child.Kind != yaml.MappingNode {
+				child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				removeKeys(parent, key)
+				parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+			}
+			parent = child
+		}
+		last := keys[len(keys)-1]
+		removeKeys(parent, last)
+		parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: last}, node)
+		l.env[strings.Join(keys, ".")] = name
+	}
+	return l
+}
+
+// envPath splits lower-cased words into config keys by walking t.
+func envPath(t reflect.Type, words []string) ([]string, reflect.Type, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if len(words) == 0 {
+		return nil, t, true
+	}
+	var fields map[string]reflect.Type
+	switch t.Kind() {
+	case reflect.Struct:
+		fields = yamlFields(t)
+	case reflect.Map:
+	default:
+		return nil, nil, false
+	}
+	for i := 1; i <= len(words); i++ {
+		key := strings.Join(words[:i], "_")
+		next := t.Elem
+		if fields != nil {
+			field, ok := fields[key]
+			if !ok {
+				continue
+			}
+			next = func() reflect.Type { return field }
+		}
+		if rest, leaf, ok := envPath(next(), words[i:]); ok {
+			return append([]string{key}, rest...), leaf, true
+		}
+	}
+	return nil, nil, false
+}
+
+func envValue(value string, t reflect.Type) *yaml.Node {
+	var parsed yaml.Node
+	if yaml.Unmarshal([]byte(value), &parsed) == nil && len(parsed.Content) > 0 {
+		node := parsed.Content[0]
+		switch {
+		case node.Kind == yaml.ScalarNode && t.Kind() != reflect.Slice,
+			node.Kind == yaml.SequenceNode && t.Kind() == reflect.Slice,
+			node.Kind == yaml.MappingNode && (t.Kind() == reflect.Map || t.Kind() == reflect.Struct):
+			return node
+		}
+	}
+	if t.Kind() == reflect.Slice {
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: item})
+			}
+		}
+		return seq
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// setLine gives every node of an env value the same line, so a type error,
+// which yaml reports by line only, can be traced to its variable.
+func setLine(node *yaml.Node, line int) {
+	node.Line, node.Column = line, 1
+	for _, child := range node.Content {
+		setLine(child, line)
+	}
+}
+
+// Source reports where the value at a key path (e.g. modules.prometheus.url)
+// came from; the zero Source means the built-in default.
+func (c *NexusConfig) Source(p string) Source {
+	return c.sources[p]
+}
+
+// Profile returns the profiles: entry Load applied, if any.
+func (c *NexusConfig) Profile() string {
+	return c.profile
+}
+
+// Files returns the config files merged into c, in order.
+func (c *NexusConfig) Files() []string {
+	return append([]string(nil), c.files...)
+}
+
+// Dump renders the effective config as YAML with secrets redacted. With
+// sources, each value carries a comment naming the layer that set it.
+func (c *NexusConfig) Dump(sources bool) ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(c); err != nil {
+		return nil, err
+	}
+	annotate(&node, "", c, sources)
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), encoder.Close()
+}
+
+func annotate(node *yaml.Node, p string, c *NexusConfig, sources bool) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := joinPath(p, key.Value)
+			annotate(value, keyPath, c, sources)
+			if sources && value.Kind != yaml.ScalarNode && len(value.Content) == 0 {
+				value.LineComment = c.Source(keyPath).String()
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			annotate(item, fmt.Sprintf("%s[%d]", p, i), c, sources)
+		}
+	case yaml.ScalarNode:
+		if redacted := c.Redact(node.Value); redacted != node.Value {
+			node.Value, node.Tag, node.Style = redacted, "!!str", 0
+		}
+		if sources {
+			node.LineComment = c.Source(p).String()
+		}
+	}
+}
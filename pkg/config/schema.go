@@ -0,0 +1,34 @@
+package config
+
+import (
+	"sync"
+
+	"github.com/edgeopslabs/nexus/pkg/schema"
+	"github.com/invopop/jsonschema"
+)
+
+// layerSchema describes one layer: the main file without include: and
+// profiles:, an included file, a profile overlay or the env overrides.
+var layerSchema = sync.OnceValue(func() *jsonschema.Schema {
+	return schema.Reflect(&NexusConfig{}, "Nexus configuration")
+})
+
+// Schema returns the JSON Schema of nexus.yaml, for editors and for
+// "nexus schema config". Load checks every layer against it.
+func Schema() *jsonschema.Schema {
+	s := schema.Reflect(&NexusConfig{}, "Nexus configuration")
+	// Overlays share the root's $defs.
+	overlay := schema.Reflect(&NexusConfig{}, "")
+	overlay.Version, overlay.Definitions = "", nil
+	s.Properties.Set("include", &jsonschema.Schema{
+		Type:        "array",
+		Items:       &jsonschema.Schema{Type: "string"},
+		Description: "Files merged after this one, relative to it; globs allowed.",
+	})
+	s.Properties.Set("profiles", &jsonschema.Schema{
+		Type:                 "object",
+		AdditionalProperties: overlay,
+		Description:          "Named overlays selected with --profile.",
+	})
+	return s
+}
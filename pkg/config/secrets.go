@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces secret values in logs and API output.
+const Redacted = "[REDACTED]"
+
+// minSecretLength is the shortest secret Redact masks. Shorter values, such
+// as env:DEBUG=1, would mask every occurrence of a common substring.
+const minSecretLength = 4
+
+// secret is a value read through an env: or file: reference, with the
+// config path it was read for.
+type secret struct {
+	path  string
+	value string
+}
+
+var envReference = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// resolveReferences rewrites every scalar value under node in place:
+// "${NAME}" and "${NAME:-default}" are replaced by environment variables
+// ("$$" is a literal "$"), then a whole value of "env:NAME" or "file:PATH"
+// is replaced by the variable or the file's content. Values read through
+// env: or file: are returned as secrets.
+func resolveReferences(node *yaml.Node, prefix string) ([]Problem, []secret) {
+	var problems []Problem
+	var secrets []secret
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			p, s := resolveReferences(child, prefix)
+			problems, secrets = append(problems, p...), append(secrets, s...)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			p, s := resolveReferences(node.Content[i+1], joinPath(prefix, node.Content[i].Value))
+			problems, secrets = append(problems, p...), append(secrets, s...)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			p, s := resolveReferences(item, fmt.Sprintf("%s[%d]", prefix, i))
+			problems, secrets = append(problems, p...), append(secrets, s...)
+		}
+	case yaml.ScalarNode:
+		value, isSecret, err := resolveScalar(node.Value)
+		if err != nil {
+			problems = append(problems, Problem{Path: prefix, Line: node.Line, Column: node.Column, Message: err.Error()})
+			return problems, nil
+		}
+		if value == node.Value {
+			return nil, nil
+		}
+		node.Value = value
+		// A plain scalar was typed from its "${...}" text; type it again from
+		// the value so numbers and booleans decode. Quoted values stay strings.
+		if node.Style&(yaml.TaggedStyle|yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+			node.Tag = ""
+		}
+		if isSecret {
+			secrets = append(secrets, secret{path: prefix, value: value})
+		}
+	}
+	return problems, secrets
+}
+
+func resolveScalar(raw string) (string, bool, error) {
+	var missing []string
+	value := envReference.ReplaceAllStringFunc(raw, func(match string) string {
+		if match == "$$" {
+			return "$"
+		}
+		m := envReference.FindStringSubmatch(match)
+		if env, ok := os.LookupEnv(m[1]); ok && (env != "" || m[2] == "") {
+			return env
+		}
+		if m[2] != "" {
+			return m[3]
+		}
+		missing = append(missing, m[1])
+		return ""
+	})
+	if len(missing) > 0 {
+		return raw, false, fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+
+	if name, ok := strings.CutPrefix(value, "env:"); ok {
+		env, set := os.LookupEnv(name)
+		if !set {
+			return raw, false, fmt.Errorf("env:%s: environment variable is not set", name)
+		}
+		return env, true, nil
+	}
+	if file, ok := strings.CutPrefix(value, "file:"); ok {
+		data, err := os.ReadFile(ExpandHome(file))
+		if err != nil {
+			return raw, false, fmt.Errorf("file:%s: %v", file, unwrapPathError(err))
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	}
+	return value, false, nil
+}
+
+// keepSecrets returns the values of secrets long enough to redact, and
+// warns about the others, which Redact leaves in place.
+func keepSecrets(secrets []secret) []string {
+	values := make([]string, 0, len(secrets))
+	for _, s := range secrets {
+		if len(s.value) < minSecretLength {
+			slog.Warn("secret is too short to redact and may appear in logs and API output",
+				"path", s.path, "min_length", minSecretLength)
+			continue
+		}
+		values = append(values, s.value)
+	}
+	return values
+}
+
+// Redact replaces every secret resolved from an env: or file: reference
+// with Redacted, except those keepSecrets dropped as too short.
+func (c *NexusConfig) Redact(s string) string {
+	for _, secret := range c.secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	return s
+}
@@ -0,0 +1,382 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is one issue found in the config. File names the layer the value
+// came from; Line and Column are 0 for defaults and env overrides.
+type Problem struct {
+	File    string
+	Path    string // dotted key, e.g. policy.allow_tools[2]
+	Line    int
+	Column  int
+	Message string
+}
+
+func (p Problem) String() string {
+	location := p.File
+	if p.Line > 0 {
+		location += fmt.Sprintf(":%d:%d", p.Line, p.Column)
+	}
+	if location != "" {
+		location += ": "
+	}
+	if p.Path == "" {
+		return location + p.Message
+	}
+	return location + p.Path + ": " + p.Message
+}
+
+// ValidationError lists every problem found in the config. The config
+// returned alongside it is usable: unknown keys are ignored and invalid
+// values are left for the consuming package to reject or default.
+type ValidationError struct {
+	File     string // main config file
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = p.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// position points scalars at their value and anything else at its key.
+func position(key, value *yaml.Node) *yaml.Node {
+	if value.Kind == yaml.ScalarNode {
+		return value
+	}
+	return key
+}
+
+// yamlFields maps the yaml keys a struct accepts to their types, flattening
+// ",inline" fields.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if opts == "inline" {
+			for key, typ := range yamlFields(field.Type) {
+				fields[key] = typ
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+var typeErrorLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// typeProblem turns a yaml.TypeError entry ("line 3: cannot unmarshal ...")
+// into a Problem, attributing it to the scalar value on that line of doc.
+func typeProblem(msg string, doc *yaml.Node, prefix string) Problem {
+	m := typeErrorLine.FindStringSubmatch(msg)
+	if m == nil {
+		return Problem{Path: prefix, Message: msg}
+	}
+	line, _ := strconv.Atoi(m[1])
+	problem := Problem{Line: line, Message: m[2]}
+	problem.Path, problem.Column = scalarOnLine(doc, prefix, line)
+	return problem
+}
+
+func scalarOnLine(node *yaml.Node, p string, line int) (string, int) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Line == line {
+			return p, node.Column
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if found, column := scalarOnLine(node.Content[i+1], joinPath(p, node.Content[i].Value), line); found != "" {
+				return found, column
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			if found, column := scalarOnLine(item, fmt.Sprintf("%s[%d]", p, i), line); found != "" {
+				return found, column
+			}
+		}
+	}
+	return "", 0
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// locate attributes each problem to the layer that set its key path, or
+// the nearest parent path a layer set.
+func locate(problems []Problem, sources map[string]Source) {
+	for i := range problems {
+		if problems[i].File != "" {
+			continue
+		}
+		for p := problems[i].Path; p != ""; p = parentPath(p) {
+			if source, ok := sources[p]; ok {
+				if source.Env != "" {
+					problems[i].File = source.String()
+				} else {
+					problems[i].File, problems[i].Line, problems[i].Column = source.File, source.Line, source.Column
+				}
+				break
+			}
+		}
+	}
+}
+
+func parentPath(p string) string {
+	if i := strings.LastIndexAny(p, ".["); i >= 0 {
+		return p[:i]
+	}
+	return ""
+}
+
+// Validate reports semantic problems in a decoded config: malformed
+// durations, URLs and glob patterns and settings that cannot work together.
+// Unknown keys and enum values are left to Schema. Settings of disabled
+// features are still checked, so turning one on later does not surprise.
+func Validate(cfg *NexusConfig) []Problem {
+	v := &validator{}
+
+	s := cfg.Server
+	v.duration("server.shutdown_timeout", s.ShutdownTimeout)
+	v.duration("server.health_timeout", s.HealthTimeout)
+	v.duration("server.config_watch", s.ConfigWatch)
+	if s.TLS.Enabled() && (s.TLS.CertFile == "" || s.TLS.KeyFile == "") {
+		v.add("server.tls", "cert_file and key_file must be set together")
+	}
+	v.duration("server.tls.reload_interval", s.TLS.ReloadInterval)
+
+	webhook, queue := false, false
+	for _, method := range cfg.Confirm.Methods {
+		webhook = webhook || strings.EqualFold(method, "webhook")
+		queue = queue || strings.EqualFold(method, "queue")
+	}
+	v.duration("confirm.timeout", cfg.Confirm.Timeout)
+	if webhook && cfg.Confirm.Webhook.URL == "" {
+		v.add("confirm.webhook.url", "required when confirm.methods includes webhook")
+	}
+	v.url("confirm.webhook.url", cfg.Confirm.Webhook.URL)
+	// Without authentication every MCP caller could approve its own calls
+	// on the MCP listener, so the queue needs a listener of its own.
+	if queue && len(s.Auth.Methods) == 0 && cfg.Confirm.Queue.Addr == "" {
+		v.add("confirm.queue.addr", "required when confirm.methods includes queue and server.auth.methods is empty")
+	}
+	v.globs("confirm.queue.approvers", cfg.Confirm.Queue.Approvers)
+
+	v.policy("policy", cfg.Policy)
+	names := make([]string, 0, len(cfg.Policy.Profiles))
+	for name := range cfg.Policy.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profile := cfg.Policy.Profiles[name]
+		prefix := "policy.profiles." + name
+		v.globs(prefix+".identities", profile.Identities)
+		v.policy(prefix, profile.PolicyConfig)
+	}
+
+	for i, limit := range cfg.RateLimits {
+		prefix := fmt.Sprintf("rate_limits[%d]", i)
+		v.globs(prefix+".tools", limit.Tools)
+		v.globs(prefix+".identities", limit.Identities)
+	}
+	for i, override := range cfg.Execution.Timeouts {
+		v.globs(fmt.Sprintf("execution.timeouts[%d].tools", i), override.Tools)
+	}
+
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		v.add("tracing.sample_ratio", "must be between 0 and 1")
+	}
+
+	m := cfg.Modules
+	// A path someone wrote down must exist. The default kubeconfig is
+	// legitimately absent inside a cluster, so only warn about it outside.
+	if m.Kubernetes.Enabled {
+		kubeconfig := ExpandHome(m.Kubernetes.Kubeconfig)
+		_, err := os.Stat(kubeconfig)
+		switch {
+		case err == nil:
+		case m.Kubernetes.Kubeconfig != defaultKubeconfig:
+			v.add("modules.kubernetes.kubeconfig", fmt.Sprintf("kubeconfig %s: %v", kubeconfig, unwrapPathError(err)))
+		case os.Getenv("KUBERNETES_SERVICE_HOST") == "":
+			slog.Warn("default kubeconfig not found and not running in a cluster; kubernetes tools will fail",
+				"path", kubeconfig, "error", unwrapPathError(err))
+		}
+	}
+	v.contexts("modules.kubernetes.contexts", m.Kubernetes.Contexts)
+	for _, name := range instances(v, "modules.kubernetes.instances", m.Kubernetes.Instances) {
+		kubeconfig := m.Kubernetes.Instances[name].Kubeconfig
+		if m.Kubernetes.Enabled && kubeconfig != "" {
+			if _, err := os.Stat(ExpandHome(kubeconfig)); err != nil {
+				v.add("modules.kubernetes.instances."+name+".kubeconfig", fmt.Sprintf("kubeconfig %s: %v", ExpandHome(kubeconfig), unwrapPathError(err)))
+			}
+		}
+		v.contexts("modules.kubernetes.instances."+name+".contexts", m.Kubernetes.Instances[name].Contexts)
+	}
+	v.url("modules.prometheus.url", m.Prometheus.URL)
+	for _, name := range instances(v, "modules.prometheus.instances", m.Prometheus.Instances) {
+		v.url("modules.prometheus.instances."+name+".url", m.Prometheus.Instances[name].URL)
+	}
+	if m.Logs.Enabled && len(m.Logs.AllowPaths) == 0 {
+		v.add("modules.logs.allow_paths", "must not be empty when the logs module is enabled")
+	}
+	if m.Logs.Enabled {
+		for i, root := range m.Logs.AllowPaths {
+			if _, err := os.Stat(root); err != nil {
+				v.add(fmt.Sprintf("modules.logs.allow_paths[%d]", i), fmt.Sprintf("%s: %v", root, unwrapPathError(err)))
+			}
+		}
+	}
+
+	return v.problems
+}
+
+type validator struct {
+	problems []Problem
+}
+
+var instanceName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// instances checks the names of a module's instances, which become part of
+// module names such as prometheus@eu, and returns them sorted.
+func instances[T any](v *validator, path string, entries map[string]T) []string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		if !instanceName.MatchString(name) {
+			v.add(path+"."+name, "instance names may only contain letters, digits, '-' and '_'")
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (v *validator) add(path, message string) {
+	v.problems = append(v.problems, Problem{Path: path, Message: message})
+}
+
+func (v *validator) duration(path, value string) {
+	if value == "" || value == "0" {
+		return
+	}
+	if parsed, err := time.ParseDuration(value); err != nil || parsed < 0 {
+		v.add(path, fmt.Sprintf("invalid duration %q (use e.g. 30s, 5m)", value))
+	}
+}
+
+func (v *validator) url(path, value string) {
+	if value == "" {
+		return
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		v.add(path, fmt.Sprintf("invalid url: %v", err))
+		return
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		v.add(path, fmt.Sprintf("invalid url %q: expected http(s)://host[:port]", value))
+	}
+}
+
+func (v *validator) contexts(prefix string, names []string) {
+	seen := make(map[string]bool, len(names))
+	for i, name := range names {
+		switch {
+		case strings.TrimSpace(name) == "":
+			v.add(fmt.Sprintf("%s[%d]", prefix, i), "must not be empty")
+		case seen[name]:
+			v.add(fmt.Sprintf("%s[%d]", prefix, i), fmt.Sprintf("duplicate context %q", name))
+		}
+		seen[name] = true
+	}
+}
+
+func (v *validator) globs(prefix string, patterns []string) {
+	for i, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			v.add(fmt.Sprintf("%s[%d]", prefix, i), fmt.Sprintf("invalid glob %q: %v", pattern, err))
+		}
+	}
+}
+
+func (v *validator) policy(prefix string, p PolicyConfig) {
+	v.globs(prefix+".allow_modules", p.AllowModules)
+	v.globs(prefix+".deny_modules", p.DenyModules)
+	v.globs(prefix+".allow_tools", p.AllowTools)
+	v.globs(prefix+".deny_tools", p.DenyTools)
+	v.globs(prefix+".confirm_tools", p.ConfirmTools)
+	for i, rule := range p.ArgRules {
+		rulePath := fmt.Sprintf("%s.arg_rules[%d]", prefix, i)
+		v.globs(rulePath+".tools", rule.Tools)
+		keys := make([]string, 0, len(rule.Args))
+		for key := range rule.Args {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			matcher := rule.Args[key]
+			argPath := rulePath + ".args." + key
+			if matcher.Glob != "" {
+				if _, err := path.Match(matcher.Glob, ""); err != nil {
+					v.add(argPath+".glob", fmt.Sprintf("invalid glob %q: %v", matcher.Glob, err))
+				}
+			}
+			if matcher.Regex != "" {
+				if _, err := regexp.Compile(matcher.Regex); err != nil {
+					v.add(argPath+".regex", fmt.Sprintf("invalid regex: %v", err))
+				}
+			}
+		}
+	}
+}
+
+// ExpandHome resolves a leading "~/" against the user's home directory.
+func ExpandHome(p string) string {
+	if strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(p, "~/"))
+		}
+	}
+	return p
+}
+
+func unwrapPathError(err error) error {
+	if pathErr, ok := err.(*os.PathError); ok {
+		return pathErr.Err
+	}
+	return err
+}
@@ -0,0 +1,129 @@
+package confirm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/auth"
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+const (
+	MethodTTY         = "tty"
+	MethodElicitation = "elicitation"
+	MethodQueue       = "queue"
+	MethodWebhook     = "webhook"
+
+	defaultTimeout = 2 * time.Minute
+)
+
+// ErrUnavailable is returned by a Confirmer that cannot reach a human for
+// this call (no TTY, client without elicitation support, ...). The chain
+// then tries the next configured method.
+var ErrUnavailable = errors.New("confirmation unavailable")
+
+type Request struct {
+	Module   string         `json:"module"`
+	Tool     string         `json:"tool"`
+	Args     map[string]any `json:"args,omitempty"`
+	Identity *auth.Identity `json:"identity,omitempty"`
+	Session  string         `json:"session,omitempty"`
+	Reason   string         `json:"reason,omitempty"`
+}
+
+type Result struct {
+	Approved bool
+	Method   string
+	By       string
+	Detail   string
+}
+
+type Confirmer interface {
+	Confirm(ctx context.Context, req Request) (Result, error)
+}
+
+type Chain struct {
+	confirmers []Confirmer
+	names      []string
+	timeout    time.Duration
+	queue      *Queue
+}
+
+func New(cfg config.ConfirmConfig) (*Chain, error) {
+	timeout := defaultTimeout
+	if cfg.Timeout != "" {
+		parsed, err := time.ParseDuration(cfg.Timeout)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid confirm timeout %q", cfg.Timeout)
+		}
+		timeout = parsed
+	}
+
+	methods := cfg.Methods
+	if len(methods) == 0 {
+		methods = []string{MethodTTY}
+	}
+
+	chain := &Chain{timeout: timeout}
+	for _, method := range methods {
+		method = strings.ToLower(method)
+		var confirmer Confirmer
+		switch method {
+		case MethodTTY:
+			confirmer = NewTTY()
+		case MethodElicitation:
+			confirmer = NewElicitation()
+		case MethodQueue:
+			if chain.queue == nil {
+				chain.queue = NewQueue(cfg.Queue.Approvers)
+			}
+			confirmer = chain.queue
+		case MethodWebhook:
+			webhook, err := NewWebhook(cfg.Webhook, timeout)
+			if err != nil {
+				return nil, fmt.Errorf("webhook confirmer: %w", err)
+			}
+			confirmer = webhook
+		default:
+			return nil, fmt.Errorf("unknown confirm method: %s", method)
+		}
+		chain.confirmers = append(chain.confirmers, confirmer)
+		chain.names = append(chain.names, method)
+	}
+	return chain, nil
+}
+
+// Queue returns the approval queue when "queue" is one of the methods, so
+// its HTTP handler can be mounted.
+func (c *Chain) Queue() *Queue {
+	return c.queue
+}
+
+// Confirm asks each method in order until one is available. A call nobody
+// can confirm, or that is not decided within the timeout, is denied.
+func (c *Chain) Confirm(ctx context.Context, req Request) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	for i, confirmer := range c.confirmers {
+		result, err := confirmer.Confirm(ctx, req)
+		if errors.Is(err, ErrUnavailable) {
+			slog.Debug("confirmation method unavailable", "method", c.names[i], "module", req.Module, "tool", req.Tool, "error", err)
+			continue
+		}
+		result.Method = c.names[i]
+		if err != nil {
+			slog.Warn("confirmation failed; denying tool", "method", c.names[i], "module", req.Module, "tool", req.Tool, "error", err)
+			result.Approved = false
+			result.Detail = err.Error()
+		}
+		return result
+	}
+
+	slog.Warn("no confirmation method available; denying tool", "module", req.Module, "tool", req.Tool, "methods", c.names)
+	return Result{Approved: false, Detail: "no confirmation method available"}
+}
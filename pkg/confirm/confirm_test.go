@@ -0,0 +1,217 @@
+package confirm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/auth"
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+type stubConfirmer struct {
+	result Result
+	err    error
+	calls  int
+}
+
+func (s *stubConfirmer) Confirm(context.Context, Request) (Result, error) {
+	s.calls++
+	return s.result, s.err
+}
+
+func TestChainSkipsUnavailableMethods(t *testing.T) {
+	unavailable := &stubConfirmer{err: ErrUnavailable}
+	approver := &stubConfirmer{result: Result{Approved: true, By: "alice"}}
+	chain := &Chain{
+		confirmers: []Confirmer{unavailable, approver},
+		names:      []string{MethodTTY, MethodWebhook},
+		timeout:    time.Second,
+	}
+
+	result := chain.Confirm(context.Background(), Request{Module: "kubernetes", Tool: "k8s_scale"})
+	if !result.Approved || result.Method != MethodWebhook || result.By != "alice" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if unavailable.calls != 1 || approver.calls != 1 {
+		t.Fatalf("expected both confirmers to be asked once")
+	}
+}
+
+func TestChainDeniesWhenNothingAvailable(t *testing.T) {
+	chain := &Chain{
+		confirmers: []Confirmer{&stubConfirmer{err: ErrUnavailable}},
+		names:      []string{MethodElicitation},
+		timeout:    time.Second,
+	}
+	if result := chain.Confirm(context.Background(), Request{}); result.Approved {
+		t.Fatalf("expected denial, got %+v", result)
+	}
+}
+
+func TestNewRejectsUnknownMethod(t *testing.T) {
+	if _, err := New(config.ConfirmConfig{Methods: []string{"carrier-pigeon"}}); err == nil {
+		t.Fatalf("expected error for unknown method")
+	}
+	if _, err := New(config.ConfirmConfig{Methods: []string{"webhook"}}); err == nil {
+		t.Fatalf("expected error for webhook without url")
+	}
+}
+
+func TestQueueApproveOverHTTP(t *testing.T) {
+	queue := NewQueue(nil)
+	handler := queue.Handler("/approvals")
+
+	done := make(chan Result, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		result, _ := queue.Confirm(ctx, Request{Module: "docker", Tool: "docker_restart"})
+		done <- result
+	}()
+
+	var pending []Approval
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/approvals", nil))
+		if err := json.Unmarshal(rec.Body.Bytes(), &pending); err != nil {
+			t.Fatalf("decode list: %v", err)
+		}
+		if len(pending) == 1 {
+			break
+		}
+	}
+	if len(pending) != 1 || pending[0].Request.Tool != "docker_restart" {
+		t.Fatalf("expected one pending approval, got %+v", pending)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/approvals/"+pending[0].ID+"/approve", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("approve returned %d: %s", rec.Code, rec.Body.String())
+	}
+
+	result := <-done
+	if !result.Approved || result.By != "anonymous" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(queue.List()) != 0 {
+		t.Fatalf("expected queue to be empty after decision")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/approvals/"+pending[0].ID+"/reject", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for resolved approval, got %d", rec.Code)
+	}
+}
+
+func TestQueueOnlyLetsOtherApproversDecide(t *testing.T) {
+	queue := NewQueue([]string{"oncall-*"})
+	agent := &auth.Identity{Method: "jwt", Subject: "oncall-bot"}
+	done := make(chan Result, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		result, _ := queue.Confirm(ctx, Request{Module: "docker", Tool: "docker_restart", Identity: agent})
+		done <- result
+	}()
+	var pending []Approval
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline) && len(pending) == 0; time.Sleep(10 * time.Millisecond) {
+		pending = queue.List()
+	}
+	if len(pending) != 1 {
+		t.Fatalf("expected one pending approval, got %+v", pending)
+	}
+	handler := queue.Handler("/approvals")
+	post := func(identity *auth.Identity, action string) int {
+		req := httptest.NewRequest(http.MethodPost, "/approvals/"+pending[0].ID+"/"+action, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req.WithContext(auth.WithIdentity(req.Context(), identity)))
+		return rec.Code
+	}
+
+	if code := post(&auth.Identity{Method: "jwt", Subject: "intern"}, "approve"); code != http.StatusForbidden {
+		t.Fatalf("expected a non-approver to be refused, got %d", code)
+	}
+	if code := post(nil, "approve"); code != http.StatusForbidden {
+		t.Fatalf("expected an anonymous caller to be refused, got %d", code)
+	}
+	if code := post(agent, "approve"); code != http.StatusForbidden {
+		t.Fatalf("expected self-approval to be refused, got %d", code)
+	}
+	if code := post(&auth.Identity{Method: "jwt", Subject: "oncall-alice"}, "approve"); code != http.StatusOK {
+		t.Fatalf("expected another approver to approve, got %d", code)
+	}
+	if result := <-done; !result.Approved || result.By != "jwt:oncall-alice" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestQueueExpires(t *testing.T) {
+	queue := NewQueue(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	result, err := queue.Confirm(ctx, Request{Module: "logs", Tool: "logs_tail"})
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if result.Approved || result.Detail != "approval expired" {
+		t.Fatalf("expected expiry, got %+v", result)
+	}
+}
+
+func TestWebhookConfirmer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Nexus-Key") != "k" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var req Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_ = json.NewEncoder(w).Encode(map[string]any{"approved": req.Tool == "k8s_scale", "approver": "oncall"})
+	}))
+	defer srv.Close()
+
+	webhook, err := NewWebhook(config.ConfirmWebhookConfig{URL: srv.URL, Headers: map[string]string{"X-Nexus-Key": "k"}}, time.Second)
+	if err != nil {
+		t.Fatalf("new webhook: %v", err)
+	}
+	result, err := webhook.Confirm(context.Background(), Request{Module: "kubernetes", Tool: "k8s_scale"})
+	if err != nil || !result.Approved || result.By != "oncall" {
+		t.Fatalf("unexpected result: %+v err=%v", result, err)
+	}
+
+	denied, err := NewWebhook(config.ConfirmWebhookConfig{URL: srv.URL}, time.Second)
+	if err != nil {
+		t.Fatalf("new webhook: %v", err)
+	}
+	if _, err := denied.Confirm(context.Background(), Request{Tool: "k8s_scale"}); err == nil {
+		t.Fatalf("expected error on non-2xx response")
+	}
+}
+
+func TestWebhookConfirmerTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	webhook, err := NewWebhook(config.ConfirmWebhookConfig{URL: srv.URL}, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("new webhook: %v", err)
+	}
+	start := time.Now()
+	if _, err := webhook.Confirm(context.Background(), Request{Tool: "k8s_scale"}); err == nil {
+		t.Fatalf("expected a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("webhook waited %s", elapsed)
+	}
+}
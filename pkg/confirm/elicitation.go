@@ -0,0 +1,70 @@
+package confirm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Elicitation asks the connected MCP client to confirm the call via an
+// elicitation/create request, if the client declared that capability.
+type Elicitation struct{}
+
+func NewElicitation() *Elicitation {
+	return &Elicitation{}
+}
+
+func (e *Elicitation) Confirm(ctx context.Context, req Request) (Result, error) {
+	mcpServer := server.ServerFromContext(ctx)
+	session := server.ClientSessionFromContext(ctx)
+	if mcpServer == nil || session == nil {
+		return Result{}, fmt.Errorf("%w: no client session", ErrUnavailable)
+	}
+	if info, ok := session.(server.SessionWithClientInfo); !ok || info.GetClientCapabilities().Elicitation == nil {
+		return Result{}, fmt.Errorf("%w: client does not support elicitation", ErrUnavailable)
+	}
+
+	message := fmt.Sprintf("Nexus needs confirmation to run %s/%s.", req.Module, req.Tool)
+	if req.Reason != "" {
+		message += " " + req.Reason
+	}
+	if len(req.Args) > 0 {
+		var parts []string
+		for key, value := range req.Args {
+			parts = append(parts, fmt.Sprintf("%s=%v", key, value))
+		}
+		message += " Arguments: " + strings.Join(parts, ", ")
+	}
+
+	result, err := mcpServer.RequestElicitation(ctx, mcp.ElicitationRequest{
+		Params: mcp.ElicitationParams{
+			Message: message,
+			RequestedSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"approve": map[string]any{
+						"type":        "boolean",
+						"description": "Approve this tool call",
+					},
+				},
+				"required": []string{"approve"},
+			},
+		},
+	})
+	if err == server.ErrElicitationNotSupported || err == server.ErrNoActiveSession {
+		return Result{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	if result.Action != mcp.ElicitationResponseActionAccept {
+		return Result{Approved: false, By: "client", Detail: string(result.Action)}, nil
+	}
+	content, _ := result.Content.(map[string]any)
+	approved, _ := content["approve"].(bool)
+	return Result{Approved: approved, By: "client"}, nil
+}
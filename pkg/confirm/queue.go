@@ -0,0 +1,202 @@
+package confirm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/auth"
+)
+
+var (
+	ErrNotFound = errors.New("approval not found")
+	// ErrForbidden is returned when the caller is not an approver, or tries
+	// to approve its own call.
+	ErrForbidden = errors.New("not allowed to decide this approval")
+)
+
+// Approval is a tool call waiting for an operator decision.
+type Approval struct {
+	ID        string    `json:"id"`
+	Request   Request   `json:"request"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type decision struct {
+	approved bool
+	by       string
+}
+
+type pendingApproval struct {
+	approval Approval
+	decided  chan decision
+}
+
+// Queue holds calls pending approval until an operator approves or rejects
+// them over HTTP, or the confirmation timeout expires.
+type Queue struct {
+	mu        sync.Mutex
+	pending   map[string]*pendingApproval
+	approvers []string // identity globs; empty allows anyone
+}
+
+// NewQueue returns a queue whose approvals only identities matching
+// approvers (see auth.MatchIdentity) may list and decide. With no
+// approvers anyone who reaches the handler may.
+func NewQueue(approvers []string) *Queue {
+	return &Queue{pending: make(map[string]*pendingApproval), approvers: approvers}
+}
+
+// mayDecide reports whether identity may see and decide approvals.
+func (q *Queue) mayDecide(identity *auth.Identity) bool {
+	return len(q.approvers) == 0 || auth.MatchIdentity(q.approvers, identity)
+}
+
+func (q *Queue) Confirm(ctx context.Context, req Request) (Result, error) {
+	id, err := newID()
+	if err != nil {
+		return Result{}, err
+	}
+	now := time.Now().UTC()
+	expires, ok := ctx.Deadline()
+	if !ok {
+		expires = now.Add(defaultTimeout)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, expires)
+		defer cancel()
+	}
+
+	entry := &pendingApproval{
+		approval: Approval{ID: id, Request: req, CreatedAt: now, ExpiresAt: expires.UTC()},
+		decided:  make(chan decision, 1),
+	}
+	q.mu.Lock()
+	q.pending[id] = entry
+	q.mu.Unlock()
+	defer func() {
+		q.mu.Lock()
+		delete(q.pending, id)
+		q.mu.Unlock()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return Result{Approved: false, Detail: "approval expired"}, nil
+	case d := <-entry.decided:
+		return Result{Approved: d.approved, By: d.by}, nil
+	}
+}
+
+// List returns pending approvals, oldest first.
+func (q *Queue) List() []Approval {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	approvals := make([]Approval, 0, len(q.pending))
+	for _, entry := range q.pending {
+		approvals = append(approvals, entry.approval)
+	}
+	sort.Slice(approvals, func(i, j int) bool {
+		return approvals[i].CreatedAt.Before(approvals[j].CreatedAt)
+	})
+	return approvals
+}
+
+// Resolve records a decision by the identity by for a pending approval.
+// Only approvers may decide, and an authenticated caller may not approve
+// its own call.
+func (q *Queue) Resolve(id string, approved bool, by *auth.Identity) error {
+	if !q.mayDecide(by) {
+		return ErrForbidden
+	}
+	q.mu.Lock()
+	entry, ok := q.pending[id]
+	if ok && approved && sameIdentity(entry.approval.Request.Identity, by) {
+		q.mu.Unlock()
+		return ErrForbidden
+	}
+	if ok {
+		delete(q.pending, id)
+	}
+	q.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+	entry.decided <- decision{approved: approved, by: by.String()}
+	return nil
+}
+
+// sameIdentity reports whether two authenticated identities are the same
+// caller. Anonymous callers cannot be told apart, so they never match.
+func sameIdentity(a, b *auth.Identity) bool {
+	return a != nil && b != nil && a.Method == b.Method && a.Subject == b.Subject
+}
+
+// Handler serves the queue under prefix:
+//
+//	GET  prefix                 list pending approvals
+//	POST prefix/{id}/approve    approve a call
+//	POST prefix/{id}/reject     reject a call
+func (q *Queue) Handler(prefix string) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		if rest == "" {
+			if r.Method != http.MethodGet {
+				w.Header().Set("Allow", http.MethodGet)
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			if !q.mayDecide(auth.FromContext(r.Context())) {
+				http.Error(w, ErrForbidden.Error(), http.StatusForbidden)
+				return
+			}
+			writeJSON(w, http.StatusOK, q.List())
+			return
+		}
+
+		parts := strings.Split(rest, "/")
+		if len(parts) != 2 || (parts[1] != "approve" && parts[1] != "reject") {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		approved := parts[1] == "approve"
+		by := auth.FromContext(r.Context())
+		if err := q.Resolve(parts[0], approved, by); err != nil {
+			status := http.StatusNotFound
+			if errors.Is(err, ErrForbidden) {
+				status = http.StatusForbidden
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"id": parts[0], "approved": approved, "by": by.String()})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func newID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
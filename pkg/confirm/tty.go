@@ -0,0 +1,42 @@
+package confirm
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type TTY struct {
+	path string
+}
+
+func NewTTY() *TTY {
+	return &TTY{path: "/dev/tty"}
+}
+
+func (t *TTY) Confirm(ctx context.Context, req Request) (Result, error) {
+	tty, err := os.OpenFile(filepath.Clean(t.path), os.O_RDWR, 0)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer tty.Close()
+
+	_, _ = fmt.Fprintf(tty, "Confirm execution of %s/%s [y/N]: ", req.Module, req.Tool)
+	answer := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(tty).ReadString('\n')
+		answer <- line
+	}()
+
+	select {
+	case <-ctx.Done():
+		_, _ = fmt.Fprintln(tty, "\n(confirmation timed out)")
+		return Result{}, ctx.Err()
+	case line := <-answer:
+		response := strings.TrimSpace(strings.ToLower(line))
+		return Result{Approved: response == "y" || response == "yes", By: "tty"}, nil
+	}
+}
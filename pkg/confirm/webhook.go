@@ -0,0 +1,72 @@
+package confirm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+// Webhook posts the request to an external approver and expects a JSON
+// response of the form {"approved": true, "approver": "alice"}.
+type Webhook struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhook returns a webhook confirmer whose requests give up after
+// timeout, the confirmation timeout of the chain it belongs to.
+func NewWebhook(cfg config.ConfirmWebhookConfig, timeout time.Duration) (*Webhook, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("url is required")
+	}
+	parsed, err := url.Parse(cfg.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid url %q", cfg.URL)
+	}
+	return &Webhook{url: cfg.URL, headers: cfg.Headers, client: &http.Client{Timeout: timeout}}, nil
+}
+
+type webhookResponse struct {
+	Approved bool   `json:"approved"`
+	Approver string `json:"approver"`
+	Reason   string `json:"reason"`
+}
+
+func (w *Webhook) Confirm(ctx context.Context, req Request) (Result, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return Result{}, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for key, value := range w.headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	resp, err := w.client.Do(httpReq)
+	if err != nil {
+		return Result{}, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Result{}, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	var decoded webhookResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&decoded); err != nil {
+		return Result{}, fmt.Errorf("invalid webhook response: %w", err)
+	}
+	return Result{Approved: decoded.Approved, By: decoded.Approver, Detail: decoded.Reason}, nil
+}
@@ -0,0 +1,207 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/policy"
+	"github.com/edgeopslabs/nexus/pkg/types"
+)
+
+type timeoutOverride struct {
+	tools   []string
+	timeout time.Duration
+}
+
+// Limits bounds every tool call with a deadline and caps how many calls
+// run concurrently per module. Each instance of a module has its own pool,
+// sized by module_concurrency for the instance, then for the module.
+type Limits struct {
+	timeout     time.Duration
+	overrides   []timeoutOverride
+	concurrency int
+	perModule   map[string]int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func New(cfg config.ExecutionConfig) (*Limits, error) {
+	timeout, err := parseTimeout(cfg.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("execution.timeout: %w", err)
+	}
+	if cfg.MaxConcurrent < 0 {
+		return nil, errors.New("execution.max_concurrent must not be negative")
+	}
+	limits := &Limits{
+		timeout:     timeout,
+		concurrency: cfg.MaxConcurrent,
+		perModule:   cfg.ModuleConcurrency,
+		slots:       make(map[string]chan struct{}),
+	}
+	for module, n := range cfg.ModuleConcurrency {
+		if n < 0 {
+			return nil, fmt.Errorf("execution.module_concurrency.%s must not be negative", module)
+		}
+	}
+	for i, override := range cfg.Timeouts {
+		if len(override.Tools) == 0 {
+			return nil, fmt.Errorf("execution.timeouts[%d]: tools is required", i)
+		}
+		parsed, err := parseTimeout(override.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("execution.timeouts[%d]: %w", i, err)
+		}
+		limits.overrides = append(limits.overrides, timeoutOverride{tools: override.Tools, timeout: parsed})
+	}
+	return limits, nil
+}
+
+func parseTimeout(value string) (time.Duration, error) {
+	if value == "" || value == "0" {
+		return 0, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return parsed, nil
+}
+
+// Timeout returns the deadline for a call; 0 means none.
+func (l *Limits) Timeout(module, tool string) time.Duration {
+	for _, override := range l.overrides {
+		if policy.MatchTool(override.tools, module, tool) {
+			return override.timeout
+		}
+	}
+	return l.timeout
+}
+
+// Acquire waits for a free slot in module's pool. The returned release
+// must be called once the call has finished.
+func (l *Limits) Acquire(ctx context.Context, module string) (func(), error) {
+	slots := l.pool(module)
+	if slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Inherit takes over the pools of previous, the limits these replace on
+// reload, for modules whose cap did not change. Calls still running on
+// them keep holding their slots, so the cap holds across the reload.
+func (l *Limits) Inherit(previous *Limits) {
+	if previous == nil {
+		return
+	}
+	previous.mu.Lock()
+	defer previous.mu.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for module, slots := range previous.slots {
+		if cap(slots) == l.size(module) {
+			l.slots[module] = slots
+		}
+	}
+}
+
+// size is the cap on concurrent calls into module; 0 means none.
+func (l *Limits) size(module string) int {
+	size := l.concurrency
+	if base, _ := types.SplitInstance(module); base != module {
+		if n, ok := l.perModule[base]; ok {
+			size = n
+		}
+	}
+	if n, ok := l.perModule[module]; ok {
+		size = n
+	}
+	return size
+}
+
+func (l *Limits) pool(module string) chan struct{} {
+	size := l.size(module)
+	if size == 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slots, ok := l.slots[module]
+	if !ok {
+		slots = make(chan struct{}, size)
+		l.slots[module] = slots
+	}
+	return slots
+}
+
+// Run calls fn under the module's concurrency cap and the tool's deadline.
+// Timeouts, whether waiting for a slot or while running, come back as
+// *TimeoutError, at the deadline even when fn ignores ctx; fn then keeps
+// its slot until it returns. With no deadline of its own, the timeout
+// reported is the one ctx already carried.
+func (l *Limits) Run(ctx context.Context, module, tool string, fn func(context.Context) error) error {
+	timeout := l.Timeout(module, tool)
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); timeout == 0 || remaining < timeout {
+			timeout = remaining.Round(time.Millisecond)
+		}
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	release, err := l.Acquire(ctx, module)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return &TimeoutError{Module: module, Tool: tool, Timeout: timeout, Queued: true}
+		}
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer release()
+		done <- fn(ctx)
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// Prefer fn's own result when it returned at the deadline.
+		select {
+		case err = <-done:
+		default:
+			err = ctx.Err()
+		}
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Module: module, Tool: tool, Timeout: timeout}
+	}
+	return err
+}
+
+type TimeoutError struct {
+	Module  string
+	Tool    string
+	Timeout time.Duration
+	Queued  bool // the deadline passed while waiting for a free slot
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Queued {
+		return fmt.Sprintf("%s/%s timed out after %s waiting for a free %s slot; too many concurrent calls", e.Module, e.Tool, e.Timeout, e.Module)
+	}
+	return fmt.Sprintf("%s/%s timed out after %s", e.Module, e.Tool, e.Timeout)
+}
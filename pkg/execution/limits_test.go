@@ -0,0 +1,180 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+func TestTimeoutOverrides(t *testing.T) {
+	limits, err := New(config.ExecutionConfig{
+		Timeout: "60s",
+		Timeouts: []config.TimeoutOverride{
+			{Tools: []string{"docker_get_logs"}, Timeout: "5s"},
+			{Tools: []string{"docker/*"}, Timeout: "2m"},
+			{Tools: []string{"plugins/*"}, Timeout: "0"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("new limits: %v", err)
+	}
+	cases := map[[2]string]time.Duration{
+		{"docker", "docker_get_logs"}:   5 * time.Second,
+		{"docker", "docker_inspect"}:    2 * time.Minute,
+		{"plugins", "restart_service"}:  0,
+		{"kubernetes", "k8s_list_pods"}: time.Minute,
+	}
+	for call, want := range cases {
+		if got := limits.Timeout(call[0], call[1]); got != want {
+			t.Fatalf("%s/%s: got %s, want %s", call[0], call[1], got, want)
+		}
+	}
+}
+
+func TestRunReportsTimeout(t *testing.T) {
+	limits, err := New(config.ExecutionConfig{Timeout: "20ms"})
+	if err != nil {
+		t.Fatalf("new limits: %v", err)
+	}
+	err = limits.Run(context.Background(), "docker", "docker_get_logs", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) || timeout.Queued || timeout.Error() != "docker/docker_get_logs timed out after 20ms" {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+}
+
+func TestRunBoundsConcurrencyPerModule(t *testing.T) {
+	limits, err := New(config.ExecutionConfig{
+		Timeout:           "50ms",
+		MaxConcurrent:     4,
+		ModuleConcurrency: map[string]int{"plugins": 1},
+	})
+	if err != nil {
+		t.Fatalf("new limits: %v", err)
+	}
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	go func() {
+		_ = limits.Run(context.Background(), "plugins", "slow", func(ctx context.Context) error {
+			close(started)
+			<-finish
+			return nil
+		})
+	}()
+	<-started
+
+	err = limits.Run(context.Background(), "plugins", "other", func(context.Context) error {
+		t.Fatalf("second plugins call should not run while the slot is taken")
+		return nil
+	})
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) || !timeout.Queued {
+		t.Fatalf("expected queued timeout, got %v", err)
+	}
+
+	ran := false
+	if err := limits.Run(context.Background(), "kubernetes", "k8s_list_pods", func(context.Context) error {
+		ran = true
+		return nil
+	}); err != nil || !ran {
+		t.Fatalf("other modules have their own pool: %v", err)
+	}
+	close(finish)
+}
+
+func TestInheritKeepsBusyPools(t *testing.T) {
+	cfg := config.ExecutionConfig{Timeout: "50ms", ModuleConcurrency: map[string]int{"plugins": 1, "docker": 1}}
+	before, err := New(cfg)
+	if err != nil {
+		t.Fatalf("new limits: %v", err)
+	}
+	releasePlugins, _ := before.Acquire(context.Background(), "plugins")
+	defer releasePlugins()
+	releaseDocker, _ := before.Acquire(context.Background(), "docker")
+	defer releaseDocker()
+
+	cfg.ModuleConcurrency = map[string]int{"plugins": 1, "docker": 2}
+	after, err := New(cfg)
+	if err != nil {
+		t.Fatalf("new limits: %v", err)
+	}
+	after.Inherit(before)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := after.Acquire(ctx, "plugins"); err == nil {
+		t.Fatalf("expected the call running before the reload to hold the only plugins slot")
+	}
+	if _, err := after.Acquire(context.Background(), "docker"); err != nil {
+		t.Fatalf("expected a resized pool to start fresh: %v", err)
+	}
+}
+
+func TestNewValidatesExecution(t *testing.T) {
+	bad := []config.ExecutionConfig{
+		{Timeout: "soon"},
+		{MaxConcurrent: -1},
+		{ModuleConcurrency: map[string]int{"docker": -2}},
+		{Timeouts: []config.TimeoutOverride{{Timeout: "5s"}}},
+		{Timeouts: []config.TimeoutOverride{{Tools: []string{"x"}, Timeout: "-1s"}}},
+	}
+	for _, cfg := range bad {
+		if _, err := New(cfg); err == nil {
+			t.Fatalf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestRunReturnsAtDeadlineWhenHandlerIgnoresIt(t *testing.T) {
+	limits, err := New(config.ExecutionConfig{Timeout: "20ms", MaxConcurrent: 1})
+	if err != nil {
+		t.Fatalf("new limits: %v", err)
+	}
+	unblock := make(chan struct{})
+	start := time.Now()
+	err = limits.Run(context.Background(), "plugins", "job", func(context.Context) error {
+		<-unblock
+		return nil
+	})
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) || time.Since(start) > time.Second {
+		t.Fatalf("expected a timeout at the deadline, got %v after %s", err, time.Since(start))
+	}
+
+	// The handler still holds the only slot until it returns.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limits.Acquire(ctx, "plugins"); err == nil {
+		t.Fatal("expected the slot to stay taken while the handler runs")
+	}
+	close(unblock)
+	release, err := limits.Acquire(context.Background(), "plugins")
+	if err != nil {
+		t.Fatalf("acquire after handler returned: %v", err)
+	}
+	release()
+}
+
+func TestRunReportsParentDeadline(t *testing.T) {
+	limits, err := New(config.ExecutionConfig{})
+	if err != nil {
+		t.Fatalf("new limits: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = limits.Run(ctx, "docker", "docker_get_logs", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) || timeout.Timeout <= 0 || timeout.Timeout > 50*time.Millisecond {
+		t.Fatalf("expected the parent deadline to be reported, got %v", err)
+	}
+}
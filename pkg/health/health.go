@@ -0,0 +1,128 @@
+// Package health aggregates module health checks for /readyz and the
+// nexus_health tool.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/registry"
+	"github.com/edgeopslabs/nexus/pkg/types"
+)
+
+const (
+	StatusOK        = "ok"
+	StatusDown      = "down"
+	StatusUnchecked = "unchecked" // module has no HealthCheck
+	StatusDegraded  = "degraded"  // optional module whose Init failed; see registry.Degraded
+
+	defaultTimeout = 5 * time.Second
+)
+
+// ModuleHealth is the outcome of one module's check.
+type ModuleHealth struct {
+	Module    string `json:"module"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// Report is the aggregate served by /readyz.
+type Report struct {
+	Ready     bool           `json:"ready"`
+	CheckedAt time.Time      `json:"checked_at"`
+	Modules   []ModuleHealth `json:"modules"`
+}
+
+// Down lists the modules whose check failed.
+func (r Report) Down() []string {
+	var down []string
+	for _, m := range r.Modules {
+		if m.Status == StatusDown {
+			down = append(down, m.Module)
+		}
+	}
+	return down
+}
+
+// Redact returns the report with redact applied to every error.
+func (r Report) Redact(redact func(string) string) Report {
+	modules := make([]ModuleHealth, len(r.Modules))
+	for i, m := range r.Modules {
+		m.Error = redact(m.Error)
+		modules[i] = m
+	}
+	r.Modules = modules
+	return r
+}
+
+// Public returns the report without errors, which can carry paths, URLs
+// and backend output, for unauthenticated callers.
+func (r Report) Public() Report {
+	return r.Redact(func(string) string { return "" })
+}
+
+// Check runs every module's HealthCheck concurrently, each bounded by
+// timeout. Modules without one are reported as unchecked, and degraded
+// modules with their Init error; neither affects readiness.
+func Check(ctx context.Context, modules []types.NexusModule, timeout time.Duration) Report {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	results := make([]ModuleHealth, len(modules))
+	var wg sync.WaitGroup
+	for i, module := range modules {
+		results[i] = ModuleHealth{Module: module.Name(), Status: StatusUnchecked}
+		checker, ok := module.(types.HealthChecker)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, module types.NexusModule, checker types.HealthChecker) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			release := registry.Enter(module.Name())
+			defer release()
+			start := time.Now()
+			err := checker.HealthCheck(checkCtx)
+			results[i].LatencyMs = time.Since(start).Milliseconds()
+			if err != nil {
+				results[i].Status = StatusDown
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Status = StatusOK
+		}(i, module, checker)
+	}
+	wg.Wait()
+	for _, d := range registry.Degraded() {
+		results = append(results, ModuleHealth{Module: d.Module, Status: StatusDegraded, Error: d.Error})
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Module < results[j].Module })
+	report := Report{Ready: true, CheckedAt: time.Now().UTC(), Modules: results}
+	for _, m := range results {
+		if m.Status == StatusDown {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+// Handler serves the public report from check as JSON, with 503 when not
+// ready.
+func Handler(check func(context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := check(r.Context()).Public()
+		w.Header().Set("Content-Type", "application/json")
+		if !report.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
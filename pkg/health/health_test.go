@@ -0,0 +1,121 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type fakeModule struct {
+	name string
+	err  error
+	slow bool
+}
+
+func (m *fakeModule) Name() string                { return m.name }
+func (m *fakeModule) Init(_ *config.Config) error { return nil }
+func (m *fakeModule) GetTools() []mcp.Tool        { return nil }
+func (m *fakeModule) HandleCall(context.Context, string, map[string]interface{}) (*mcp.CallToolResult, error) {
+	return nil, nil
+}
+
+type checkedModule struct{ fakeModule }
+
+func (m *checkedModule) HealthCheck(ctx context.Context) error {
+	if m.slow {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return m.err
+}
+
+func TestCheckAggregatesModules(t *testing.T) {
+	modules := []types.NexusModule{
+		&checkedModule{fakeModule{name: "prometheus", err: errors.New("connection refused")}},
+		&fakeModule{name: "logs"},
+		&checkedModule{fakeModule{name: "kubernetes"}},
+		&checkedModule{fakeModule{name: "docker", slow: true}},
+	}
+	report := Check(context.Background(), modules, 20*time.Millisecond)
+	if report.Ready {
+		t.Fatal("expected not ready with a failing module")
+	}
+
+	got := map[string]string{}
+	for _, m := range report.Modules {
+		got[m.Module] = m.Status
+	}
+	want := map[string]string{
+		"prometheus": StatusDown,
+		"logs":       StatusUnchecked,
+		"kubernetes": StatusOK,
+		"docker":     StatusDown,
+	}
+	for module, status := range want {
+		if got[module] != status {
+			t.Fatalf("%s: got %q, want %q", module, got[module], status)
+		}
+	}
+	if report.Modules[0].Module != "docker" {
+		t.Fatalf("expected modules sorted by name, got %+v", report.Modules)
+	}
+	if down := report.Down(); len(down) != 2 {
+		t.Fatalf("expected two modules down, got %v", down)
+	}
+}
+
+func TestHandlerStatusCode(t *testing.T) {
+	for _, ready := range []bool{true, false} {
+		handler := Handler(func(context.Context) Report {
+			return Report{Ready: ready, Modules: []ModuleHealth{{Module: "docker", Status: StatusOK}}}
+		})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		want := http.StatusOK
+		if !ready {
+			want = http.StatusServiceUnavailable
+		}
+		if rec.Code != want {
+			t.Fatalf("ready=%v: got status %d, want %d", ready, rec.Code, want)
+		}
+		var report Report
+		if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if report.Ready != ready || len(report.Modules) != 1 {
+			t.Fatalf("unexpected body %+v", report)
+		}
+	}
+}
+
+func TestHandlerHidesErrors(t *testing.T) {
+	handler := Handler(func(context.Context) Report {
+		return Report{Modules: []ModuleHealth{{Module: "prometheus", Status: StatusDown, Error: "dial http://secret@prom:9090"}}}
+	})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if strings.Contains(rec.Body.String(), "secret") {
+		t.Fatalf("error leaked to /readyz: %s", rec.Body.String())
+	}
+}
+
+func TestRedactMasksErrors(t *testing.T) {
+	report := Report{Modules: []ModuleHealth{{Module: "prometheus", Status: StatusDown, Error: "token s3cr3t rejected"}}}
+	redacted := report.Redact(func(s string) string { return strings.ReplaceAll(s, "s3cr3t", "[REDACTED]") })
+	if got := redacted.Modules[0].Error; got != "token [REDACTED] rejected" {
+		t.Fatalf("got %q", got)
+	}
+	if report.Modules[0].Error != "token s3cr3t rejected" {
+		t.Fatal("Redact modified the original report")
+	}
+}
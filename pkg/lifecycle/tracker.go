@@ -0,0 +1,83 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// Tracker counts in-flight tool calls so shutdown can wait for them and, once
+// the drain deadline passes, cancel whatever is still running.
+type Tracker struct {
+	mu      sync.Mutex
+	closing bool
+	nextID  uint64
+	cancels map[uint64]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{cancels: make(map[uint64]context.CancelFunc)}
+}
+
+// Begin registers a call. The returned done func must be called when the call
+// finishes. After Drain has started, Begin fails with ErrShuttingDown.
+func (t *Tracker) Begin(ctx context.Context) (context.Context, func(), error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closing {
+		return ctx, func() {}, ErrShuttingDown
+	}
+
+	callCtx, cancel := context.WithCancel(ctx)
+	id := t.nextID
+	t.nextID++
+	t.cancels[id] = cancel
+	t.wg.Add(1)
+
+	var once sync.Once
+	done := func() {
+		once.Do(func() {
+			t.mu.Lock()
+			delete(t.cancels, id)
+			t.mu.Unlock()
+			cancel()
+			t.wg.Done()
+		})
+	}
+	return callCtx, done, nil
+}
+
+func (t *Tracker) InFlight() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.cancels)
+}
+
+// Drain stops new calls and waits for in-flight ones. If ctx expires first,
+// the remaining calls are cancelled and ctx's error is returned.
+func (t *Tracker) Drain(ctx context.Context) error {
+	t.mu.Lock()
+	t.closing = true
+	t.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		t.mu.Lock()
+		for _, cancel := range t.cancels {
+			cancel()
+		}
+		t.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,61 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDrainWaitsForInFlightCalls(t *testing.T) {
+	tracker := NewTracker()
+	_, done, err := tracker.Begin(context.Background())
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		done()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := tracker.Drain(ctx); err != nil {
+		t.Fatalf("expected clean drain, got %v", err)
+	}
+	if tracker.InFlight() != 0 {
+		t.Fatalf("expected no in-flight calls after drain")
+	}
+}
+
+func TestDrainCancelsCallsAfterDeadline(t *testing.T) {
+	tracker := NewTracker()
+	callCtx, done, err := tracker.Begin(context.Background())
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := tracker.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	select {
+	case <-callCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("expected in-flight call context to be cancelled")
+	}
+}
+
+func TestBeginRejectedWhileDraining(t *testing.T) {
+	tracker := NewTracker()
+	if err := tracker.Drain(context.Background()); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	if _, _, err := tracker.Begin(context.Background()); !errors.Is(err, ErrShuttingDown) {
+		t.Fatalf("expected ErrShuttingDown, got %v", err)
+	}
+}
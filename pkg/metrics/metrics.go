@@ -0,0 +1,152 @@
+// Package metrics exposes Prometheus metrics about Nexus itself. Recording
+// functions are package level so modules can report without plumbing.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "nexus"
+
+var (
+	registry = prometheus.NewRegistry()
+
+	toolCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_calls_total",
+		Help:      "Tool calls by module, tool and decision (allow, deny, confirm, rate_limited, rejected).",
+	}, []string{"module", "tool", "decision"})
+
+	toolDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tool_call_duration_seconds",
+		Help:      "Tool call latency, including policy checks and confirmation.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"module", "tool"})
+
+	toolErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_call_errors_total",
+		Help:      "Tool calls that returned an error, including denials.",
+	}, []string{"module", "tool"})
+
+	toolDenials = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_denials_total",
+		Help:      "Tool calls refused, by source (policy, rate_limit, confirmation).",
+	}, []string{"module", "tool", "source"})
+
+	confirmations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_confirmations_total",
+		Help:      "Confirmation outcomes by method.",
+	}, []string{"module", "tool", "method", "outcome"})
+
+	resultBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_result_bytes_total",
+		Help:      "Bytes of tool result content returned to clients.",
+	}, []string{"module", "tool"})
+
+	truncations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_truncations_total",
+		Help:      "Tool results cut short by a size or count limit.",
+	}, []string{"module", "tool"})
+
+	moduleStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "module_status",
+		Help:      "1 for the current status of each module (loaded, degraded, disabled, failed).",
+	}, []string{"module", "status"})
+
+	pluginExits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "plugin_exits_total",
+		Help:      "Plugin subprocess exits by exit code (-1 when killed by a signal).",
+	}, []string{"plugin", "code"})
+)
+
+var moduleStatuses = []string{"loaded", "degraded", "disabled", "failed"}
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		toolCalls, toolDuration, toolErrors, toolDenials, confirmations,
+		resultBytes, truncations, moduleStatus, pluginExits,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// Call describes a finished tool call.
+type Call struct {
+	Module   string
+	Tool     string
+	Decision string
+	// Confirm is the confirmation outcome, empty when none was asked for.
+	Confirm       string
+	ConfirmMethod string
+	Failed        bool
+	ResultBytes   int
+	Duration      time.Duration
+}
+
+// ObserveCall records a finished tool call.
+func ObserveCall(call Call) {
+	module, tool := call.Module, call.Tool
+	toolCalls.WithLabelValues(module, tool, call.Decision).Inc()
+	toolDuration.WithLabelValues(module, tool).Observe(call.Duration.Seconds())
+	resultBytes.WithLabelValues(module, tool).Add(float64(call.ResultBytes))
+	if call.Failed {
+		toolErrors.WithLabelValues(module, tool).Inc()
+	}
+
+	switch {
+	case call.Decision == "deny":
+		toolDenials.WithLabelValues(module, tool, "policy").Inc()
+	case call.Decision == "rate_limited":
+		toolDenials.WithLabelValues(module, tool, "rate_limit").Inc()
+	case call.Confirm == "denied":
+		toolDenials.WithLabelValues(module, tool, "confirmation").Inc()
+	}
+	if call.Confirm != "" {
+		method := call.ConfirmMethod
+		if method == "" {
+			method = "none"
+		}
+		confirmations.WithLabelValues(module, tool, method, call.Confirm).Inc()
+	}
+}
+
+// Truncated records a result cut short by a module limit.
+func Truncated(module, tool string) {
+	truncations.WithLabelValues(module, tool).Inc()
+}
+
+// SetModuleStatus marks status (loaded, degraded, disabled or failed) as the
+// module's current one.
+func SetModuleStatus(module, status string) {
+	for _, s := range moduleStatuses {
+		value := 0.0
+		if s == status {
+			value = 1
+		}
+		moduleStatus.WithLabelValues(module, s).Set(value)
+	}
+}
+
+// PluginExited records a plugin subprocess exit code.
+func PluginExited(plugin string, code int) {
+	pluginExits.WithLabelValues(plugin, strconv.Itoa(code)).Inc()
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("read metrics: %v", err)
+	}
+	return string(body)
+}
+
+func TestObserveCallExportsMetrics(t *testing.T) {
+	ObserveCall(Call{Module: "kubernetes", Tool: "k8s_list_pods", Decision: "allow", ResultBytes: 120, Duration: 30 * time.Millisecond})
+	ObserveCall(Call{Module: "kubernetes", Tool: "k8s_scale", Decision: "deny", Failed: true, Duration: time.Millisecond})
+	ObserveCall(Call{Module: "docker", Tool: "docker_restart", Decision: "confirm", Confirm: "denied", ConfirmMethod: "queue", Failed: true, Duration: time.Second})
+	Truncated("plugins", "plugin/demo/dump")
+	SetModuleStatus("prometheus", "failed")
+	SetModuleStatus("prometheus", "loaded")
+	PluginExited("demo", 2)
+
+	body := scrape(t)
+	for _, want := range []string{
+		`nexus_tool_calls_total{decision="allow",module="kubernetes",tool="k8s_list_pods"} 1`,
+		`nexus_tool_result_bytes_total{module="kubernetes",tool="k8s_list_pods"} 120`,
+		`nexus_tool_call_duration_seconds_count{module="kubernetes",tool="k8s_list_pods"} 1`,
+		`nexus_tool_denials_total{module="kubernetes",source="policy",tool="k8s_scale"} 1`,
+		`nexus_tool_denials_total{module="docker",source="confirmation",tool="docker_restart"} 1`,
+		`nexus_tool_confirmations_total{method="queue",module="docker",outcome="denied",tool="docker_restart"} 1`,
+		`nexus_tool_call_errors_total{module="docker",tool="docker_restart"} 1`,
+		`nexus_tool_truncations_total{module="plugins",tool="plugin/demo/dump"} 1`,
+		`nexus_module_status{module="prometheus",status="failed"} 0`,
+		`nexus_module_status{module="prometheus",status="loaded"} 1`,
+		`nexus_plugin_exits_total{code="2",plugin="demo"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("missing %q in metrics output", want)
+		}
+	}
+}
@@ -7,11 +7,15 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/metrics"
 	"github.com/edgeopslabs/nexus/pkg/registry"
+	"github.com/edgeopslabs/nexus/pkg/tracing"
 	"github.com/edgeopslabs/nexus/pkg/types"
 	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -19,6 +23,9 @@ const (
 	listContainers   = "docker_list_containers"
 	inspectContainer = "docker_inspect_container"
 	containerLogs    = "docker_get_logs"
+
+	// cliWaitDelay bounds how long output pipes may outlive a killed CLI.
+	cliWaitDelay = 5 * time.Second
 )
 
 type Module struct {
@@ -125,7 +132,8 @@ func (m *Module) handleLogs(ctx context.Context, args map[string]interface{}) (*
 	if id == "" {
 		return mcp.NewToolResultError("id is required"), nil
 	}
-	tailLines := clampInt(getIntArg(args, "tail_lines", 200), 1, m.cfg.Modules.Docker.MaxLines)
+	requested := getIntArg(args, "tail_lines", 200)
+	tailLines := clampInt(requested, 1, m.cfg.Modules.Docker.MaxLines)
 	sinceSeconds := getIntArg(args, "since_seconds", 0)
 	timestamps := getBoolArg(args, "timestamps", false)
 
@@ -142,14 +150,28 @@ func (m *Module) handleLogs(ctx context.Context, args map[string]interface{}) (*
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("docker logs failed: %v", err)), nil
 	}
+	// A clamped request that filled max_lines may have wanted more.
+	if requested > tailLines && strings.Count(output, "\n")+1 >= tailLines {
+		metrics.Truncated(moduleName, containerLogs)
+	}
 	if strings.TrimSpace(output) == "" {
 		output = "(no log lines)"
 	}
 	return mcp.NewToolResultText(output), nil
 }
 
-func (m *Module) runDocker(ctx context.Context, args ...string) (string, error) {
+// HealthCheck asks the Docker daemon for its version.
+func (m *Module) HealthCheck(ctx context.Context) error {
+	_, err := m.runDocker(ctx, "version", "--format", "{{.Server.Version}}")
+	return err
+}
+
+func (m *Module) runDocker(ctx context.Context, args ...string) (output string, err error) {
+	ctx, span := tracing.Start(ctx, "docker "+args[0], attribute.StringSlice("docker.args", args))
+	defer func() { tracing.End(span, err) }()
+
 	cmd := exec.CommandContext(ctx, m.cfg.Modules.Docker.CLI, args...)
+	cmd.WaitDelay = cliWaitDelay
 	data, err := cmd.CombinedOutput()
 	if err != nil {
 		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(string(data)))
@@ -219,4 +241,7 @@ func init() {
 	registry.Register(moduleName, New())
 }
 
-var _ types.NexusModule = (*Module)(nil)
+var (
+	_ types.NexusModule   = (*Module)(nil)
+	_ types.HealthChecker = (*Module)(nil)
+)
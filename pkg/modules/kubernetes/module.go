@@ -5,20 +5,28 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/metrics"
 	"github.com/edgeopslabs/nexus/pkg/registry"
+	"github.com/edgeopslabs/nexus/pkg/tracing"
 	"github.com/edgeopslabs/nexus/pkg/types"
 	"github.com/mark3labs/mcp-go/mcp"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/client-go/util/homedir"
 )
 
@@ -28,10 +36,27 @@ const (
 	logsTool           = "k8s_get_logs"
 	listNamespacesTool = "k8s_list_namespaces"
 	listPodsAllTool    = "k8s_list_pods_all"
+	listContextsTool   = "k8s_list_contexts"
+
+	// contextArg picks the kubeconfig context of a call.
+	contextArg = "context"
 )
 
 type Module struct {
-	cfg *config.Config
+	cfg      *config.Config
+	instance string                  // "" unless modules.kubernetes.instances names clusters
+	settings config.KubernetesConfig // the section, resolved for the instance
+
+	mu         sync.Mutex
+	kubeconfig cachedKubeconfig
+}
+
+// cachedKubeconfig is the last kubeconfig read from disk, reused until the
+// file's modification time changes.
+type cachedKubeconfig struct {
+	path    string
+	modTime time.Time
+	raw     *clientcmdapi.Config
 }
 
 func New() *Module {
@@ -39,7 +64,16 @@ func New() *Module {
 }
 
 func (m *Module) Name() string {
-	return moduleName
+	return types.InstanceName(moduleName, m.instance)
+}
+
+// Instances lists the configured clusters, one module each.
+func (m *Module) Instances(cfg *config.Config) []string {
+	return slices.Sorted(maps.Keys(cfg.Modules.Kubernetes.Instances))
+}
+
+func (m *Module) NewInstance(name string) types.NexusModule {
+	return &Module{instance: name}
 }
 
 func (m *Module) Enabled(cfg *config.Config) bool {
@@ -48,6 +82,7 @@ func (m *Module) Enabled(cfg *config.Config) bool {
 
 func (m *Module) Init(cfg *config.Config) error {
 	m.cfg = cfg
+	m.settings = cfg.Modules.Kubernetes.Instance(m.instance)
 	if !cfg.Modules.Kubernetes.Enabled {
 		slog.Info("kubernetes module disabled by config")
 	}
@@ -59,7 +94,7 @@ func (m *Module) GetTools() []mcp.Tool {
 		return nil
 	}
 
-	return []mcp.Tool{
+	tools := []mcp.Tool{
 		mcp.NewTool(listNamespacesTool,
 			mcp.WithDescription("List all namespaces in the cluster."),
 			mcp.WithNumber("max_namespaces", mcp.Description("Max namespaces to return (default 200, max 1000).")),
@@ -95,6 +130,27 @@ func (m *Module) GetTools() []mcp.Tool {
 			mcp.WithDestructiveHintAnnotation(false),
 		),
 	}
+	// Without further contexts only the default one is reachable, so the
+	// argument is left out.
+	if len(m.settings.Contexts) > 0 {
+		allowed, current, err := m.contexts()
+		if err != nil {
+			allowed, current = m.settings.Contexts, ""
+		}
+		description := fmt.Sprintf("Kubeconfig context to query; see %s.", listContextsTool)
+		if current != "" {
+			description = fmt.Sprintf("Kubeconfig context to query (default %s); see %s.", current, listContextsTool)
+		}
+		option := mcp.WithString(contextArg, mcp.Enum(allowed...), mcp.Description(description))
+		for i := range tools {
+			option(&tools[i])
+		}
+	}
+	return append(tools, mcp.NewTool(listContextsTool,
+		mcp.WithDescription("List the kubeconfig contexts (clusters) the other k8s_* tools can target, marking the default."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+	))
 }
 
 func (m *Module) HandleCall(ctx context.Context, name string, args map[string]interface{}) (*mcp.CallToolResult, error) {
@@ -111,6 +167,8 @@ func (m *Module) HandleCall(ctx context.Context, name string, args map[string]in
 		return m.handleListPodsAll(ctx, args)
 	case logsTool:
 		return m.handleLogs(ctx, args)
+	case listContextsTool:
+		return m.handleListContexts()
 	default:
 		return mcp.NewToolResultError(fmt.Sprintf("unknown tool: %s", name)), nil
 	}
@@ -119,9 +177,9 @@ func (m *Module) HandleCall(ctx context.Context, name string, args map[string]in
 func (m *Module) handleListNamespaces(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
 	maxNamespaces := clampInt(getIntArg(args, "max_namespaces", 200), 1, 1000)
 
-	clientset, err := m.getClient()
+	clientset, err := m.clientFor(args)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("k8s auth failed: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
 	if err != nil {
@@ -151,9 +209,9 @@ func (m *Module) handleListPods(ctx context.Context, args map[string]interface{}
 		namespace = "default"
 	}
 
-	clientset, err := m.getClient()
+	clientset, err := m.clientFor(args)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("k8s auth failed: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
@@ -182,9 +240,9 @@ func (m *Module) handleListPodsAll(ctx context.Context, args map[string]interfac
 	errorOnly := getBoolArg(args, "error_only", true)
 	maxPods := clampInt(getIntArg(args, "max_pods", 200), 1, 1000)
 
-	clientset, err := m.getClient()
+	clientset, err := m.clientFor(args)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("k8s auth failed: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
@@ -212,6 +270,7 @@ func (m *Module) handleListPodsAll(ctx context.Context, args map[string]interfac
 		output.WriteString(line + "\n")
 		if count >= maxPods {
 			output.WriteString(fmt.Sprintf("... truncated at %d pods\n", maxPods))
+			metrics.Truncated(m.Name(), listPodsAllTool)
 			break
 		}
 	}
@@ -237,9 +296,9 @@ func (m *Module) handleLogs(ctx context.Context, args map[string]interface{}) (*
 	errorOnly := getBoolArg(args, "error_only", true)
 	eventLimit := clampInt(getIntArg(args, "event_limit", 5), 1, 20)
 
-	clientset, err := m.getClient()
+	clientset, err := m.clientFor(args)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("k8s auth failed: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	var pods []corev1.Pod
@@ -255,7 +314,7 @@ func (m *Module) handleLogs(ctx context.Context, args map[string]interface{}) (*
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to get deployment %s: %v", name, err)), nil
 		}
-		pods, err = m.listPodsForSelector(ctx, namespace, deploy.Spec.Selector)
+		pods, err = listPodsForSelector(ctx, clientset, namespace, deploy.Spec.Selector)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to list pods for deployment %s: %v", name, err)), nil
 		}
@@ -264,7 +323,7 @@ func (m *Module) handleLogs(ctx context.Context, args map[string]interface{}) (*
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to get daemonset %s: %v", name, err)), nil
 		}
-		pods, err = m.listPodsForSelector(ctx, namespace, ds.Spec.Selector)
+		pods, err = listPodsForSelector(ctx, clientset, namespace, ds.Spec.Selector)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to list pods for daemonset %s: %v", name, err)), nil
 		}
@@ -273,7 +332,7 @@ func (m *Module) handleLogs(ctx context.Context, args map[string]interface{}) (*
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to get statefulset %s: %v", name, err)), nil
 		}
-		pods, err = m.listPodsForSelector(ctx, namespace, sts.Spec.Selector)
+		pods, err = listPodsForSelector(ctx, clientset, namespace, sts.Spec.Selector)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to list pods for statefulset %s: %v", name, err)), nil
 		}
@@ -282,7 +341,7 @@ func (m *Module) handleLogs(ctx context.Context, args map[string]interface{}) (*
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to get job %s: %v", name, err)), nil
 		}
-		pods, err = m.listPodsForSelector(ctx, namespace, job.Spec.Selector)
+		pods, err = listPodsForSelector(ctx, clientset, namespace, job.Spec.Selector)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to list pods for job %s: %v", name, err)), nil
 		}
@@ -325,16 +384,165 @@ func (m *Module) handleLogs(ctx context.Context, args map[string]interface{}) (*
 	return mcp.NewToolResultText(output.String()), nil
 }
 
-func (m *Module) getClient() (*kubernetes.Clientset, error) {
-	kubeconfig := resolveKubeconfig(m.cfg.Modules.Kubernetes.Kubeconfig)
-	cfg, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+func (m *Module) handleListContexts() (*mcp.CallToolResult, error) {
+	allowed, current, err := m.contexts()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load kubeconfig: %v", err)), nil
+	}
+	if len(allowed) == 0 {
+		return mcp.NewToolResultText("No kubeconfig contexts; using the in-cluster config."), nil
+	}
+	raw, err := m.rawConfig()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load kubeconfig: %v", err)), nil
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("Contexts (default: %s):\n", current))
+	for _, name := range allowed {
+		line := "- " + name
+		if raw == nil || raw.Contexts[name] == nil {
+			output.WriteString(line + " | not found in kubeconfig\n")
+			continue
+		}
+		kubeContext := raw.Contexts[name]
+		line += " | cluster=" + kubeContext.Cluster
+		if kubeContext.Namespace != "" {
+			line += " | namespace=" + kubeContext.Namespace
+		}
+		output.WriteString(line + "\n")
+	}
+	return mcp.NewToolResultText(output.String()), nil
+}
+
+// DefaultArgs sets the context argument to the default context when a call
+// leaves it out, so that policy rules on context also cover those calls.
+// Without modules.kubernetes.contexts, tools have no context argument and
+// nothing is set.
+func (m *Module) DefaultArgs(tool string, args map[string]interface{}) {
+	if len(m.settings.Contexts) == 0 || tool == listContextsTool || getStringArg(args, contextArg, "") != "" {
+		return
+	}
+	if _, current, err := m.contexts(); err == nil && current != "" {
+		args[contextArg] = current
+	}
+}
+
+// contexts returns the contexts calls may use, the default first, and the
+// default itself: the configured context, else the kubeconfig's
+// current-context. Without a kubeconfig (in-cluster) both are empty.
+func (m *Module) contexts() ([]string, string, error) {
+	current := m.settings.Context
+	if current == "" {
+		raw, err := m.rawConfig()
+		if err != nil {
+			return nil, "", err
+		}
+		if raw != nil {
+			current = raw.CurrentContext
+		}
+	}
+	var allowed []string
+	if current != "" {
+		allowed = append(allowed, current)
+	}
+	for _, name := range m.settings.Contexts {
+		if name != current {
+			allowed = append(allowed, name)
+		}
+	}
+	return allowed, current, nil
+}
+
+// rawConfig loads the kubeconfig, or returns nil when there is none to
+// load: no kubeconfig is configured and ~/.kube/config does not exist.
+// The file is read again only when its modification time changes, so
+// rotated credentials are picked up without parsing it on every call.
+func (m *Module) rawConfig() (*clientcmdapi.Config, error) {
+	kubeconfig := resolveKubeconfig(m.settings.Kubeconfig)
+	if kubeconfig == "" {
+		return nil, nil
+	}
+	info, err := os.Stat(kubeconfig)
+	if os.IsNotExist(err) && m.settings.Kubeconfig == "" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cached := m.kubeconfig; cached.raw != nil && cached.path == kubeconfig && cached.modTime.Equal(info.ModTime()) {
+		return cached.raw, nil
+	}
+	raw, err := clientcmd.LoadFromFile(kubeconfig)
 	if err != nil {
 		return nil, err
 	}
+	if err := clientcmd.ResolveLocalPaths(raw); err != nil {
+		return nil, err
+	}
+	m.kubeconfig = cachedKubeconfig{path: kubeconfig, modTime: info.ModTime(), raw: raw}
+	return raw, nil
+}
+
+// clientFor returns a client for the context a call asked for, which must
+// be the default or one of modules.kubernetes.contexts.
+func (m *Module) clientFor(args map[string]interface{}) (*kubernetes.Clientset, error) {
+	kubeContext := strings.TrimSpace(getStringArg(args, contextArg, ""))
+	if kubeContext != "" {
+		allowed, _, err := m.contexts()
+		if err != nil {
+			return nil, fmt.Errorf("k8s auth failed: %w", err)
+		}
+		if !slices.Contains(allowed, kubeContext) {
+			return nil, fmt.Errorf("context %q is not allowed; use one of: %s", kubeContext, strings.Join(allowed, ", "))
+		}
+	}
+	clientset, err := m.getClient(kubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("k8s auth failed: %w", err)
+	}
+	return clientset, nil
+}
+
+// getClient builds a client for kubeContext, or for the default context
+// when it is empty.
+func (m *Module) getClient(kubeContext string) (*kubernetes.Clientset, error) {
+	if kubeContext == "" {
+		kubeContext = m.settings.Context
+	}
+	raw, err := m.rawConfig()
+	if err != nil {
+		return nil, err
+	}
+	var cfg *rest.Config
+	if raw == nil {
+		// No kubeconfig: fall back to the in-cluster config.
+		cfg, err = rest.InClusterConfig()
+	} else {
+		cfg, err = clientcmd.NewNonInteractiveClientConfig(*raw, kubeContext, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return tracing.Transport(rt, m.Name())
+	})
 	return kubernetes.NewForConfig(cfg)
 }
 
-func (m *Module) listPodsForSelector(ctx context.Context, namespace string, selector *metav1.LabelSelector) ([]corev1.Pod, error) {
+// HealthCheck asks the API server for its version.
+func (m *Module) HealthCheck(ctx context.Context) error {
+	clientset, err := m.getClient("")
+	if err != nil {
+		return fmt.Errorf("k8s auth failed: %w", err)
+	}
+	return clientset.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+}
+
+func listPodsForSelector(ctx context.Context, clientset *kubernetes.Clientset, namespace string, selector *metav1.LabelSelector) ([]corev1.Pod, error) {
 	if selector == nil {
 		return nil, fmt.Errorf("selector not defined")
 	}
@@ -342,11 +550,7 @@ func (m *Module) listPodsForSelector(ctx context.Context, namespace string, sele
 	if err != nil {
 		return nil, err
 	}
-	list, err := m.getClient()
-	if err != nil {
-		return nil, err
-	}
-	pods, err := list.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
 		LabelSelector: labelsSelector.String(),
 	})
 	if err != nil {
@@ -639,4 +843,9 @@ func init() {
 	registry.Register(moduleName, New())
 }
 
-var _ types.NexusModule = (*Module)(nil)
+var (
+	_ types.NexusModule   = (*Module)(nil)
+	_ types.HealthChecker = (*Module)(nil)
+	_ types.MultiInstance = (*Module)(nil)
+	_ types.ArgDefaulter  = (*Module)(nil)
+)
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/metrics"
 	"github.com/edgeopslabs/nexus/pkg/registry"
 	"github.com/edgeopslabs/nexus/pkg/types"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -97,16 +98,24 @@ func (m *Module) handleTail(_ context.Context, args map[string]interface{}) (*mc
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	tailLines := clampInt(getIntArg(args, "tail_lines", 200), 1, m.cfg.Modules.Logs.MaxLines)
+	requested := getIntArg(args, "tail_lines", 200)
+	tailLines := clampInt(requested, 1, m.cfg.Modules.Logs.MaxLines)
 	contains := strings.TrimSpace(getStringArg(args, "contains", ""))
 	errorOnly := getBoolArg(args, "error_only", true)
 
-	content, err := readTail(absPath, tailLines, m.cfg.Modules.Logs.MaxBytes)
+	content, truncated, err := readTail(absPath, tailLines, m.cfg.Modules.Logs.MaxBytes)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to read logs: %v", err)), nil
 	}
+	// A clamped request that filled max_lines may have wanted more.
+	if requested > tailLines && strings.Count(content, "\n")+1 >= tailLines {
+		truncated = true
+	}
+	if truncated {
+		metrics.Truncated(moduleName, tailLogTool)
+	}
 
-	content = filterLines(content, contains, false, tailLines, errorOnly)
+	content, _ = filterLines(content, contains, false, tailLines, errorOnly)
 
 	if content == "" {
 		content = "(no matching log lines)"
@@ -131,12 +140,15 @@ func (m *Module) handleGrep(_ context.Context, args map[string]interface{}) (*mc
 	maxLines := clampInt(getIntArg(args, "max_lines", 200), 1, m.cfg.Modules.Logs.MaxLines)
 	caseSensitive := getBoolArg(args, "case_sensitive", false)
 
-	content, err := readTail(absPath, maxLines*10, m.cfg.Modules.Logs.MaxBytes)
+	content, cut, err := readTail(absPath, maxLines*10, m.cfg.Modules.Logs.MaxBytes)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to read logs: %v", err)), nil
 	}
 
-	content = filterLines(content, query, caseSensitive, maxLines, false)
+	content, more := filterLines(content, query, caseSensitive, maxLines, false)
+	if cut || more {
+		metrics.Truncated(moduleName, grepLogTool)
+	}
 	if content == "" {
 		content = "(no matching log lines)"
 	}
@@ -174,13 +186,16 @@ func (m *Module) validatePath(input string) (string, error) {
 	return "", fmt.Errorf("log path not allowed: %s", resolved)
 }
 
-func readTail(path string, tailLines int, maxBytes int) (string, error) {
+// readTail returns up to tailLines last lines of path, reading at most
+// maxBytes. It reports whether the byte limit left out lines that would
+// otherwise have been returned.
+func readTail(path string, tailLines int, maxBytes int) (string, bool, error) {
 	info, err := os.Stat(path)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 	if info.IsDir() {
-		return "", fmt.Errorf("path is a directory")
+		return "", false, fmt.Errorf("path is a directory")
 	}
 	if maxBytes <= 0 {
 		maxBytes = 256 * 1024
@@ -188,7 +203,7 @@ func readTail(path string, tailLines int, maxBytes int) (string, error) {
 
 	file, err := os.Open(path)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 	defer file.Close()
 
@@ -199,21 +214,24 @@ func readTail(path string, tailLines int, maxBytes int) (string, error) {
 		start = size - limit
 	}
 	if _, err := file.Seek(start, io.SeekStart); err != nil {
-		return "", err
+		return "", false, err
 	}
 	data, err := io.ReadAll(file)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 
 	lines := strings.Split(string(data), "\n")
+	truncated := start > 0 && len(lines) <= tailLines
 	if len(lines) > tailLines {
 		lines = lines[len(lines)-tailLines:]
 	}
-	return strings.Join(lines, "\n"), nil
+	return strings.Join(lines, "\n"), truncated, nil
 }
 
-func filterLines(content, query string, caseSensitive bool, maxLines int, errorOnly bool) string {
+// filterLines keeps up to maxLines lines that contain query and, with
+// errorOnly, look like errors. It reports whether more lines matched.
+func filterLines(content, query string, caseSensitive bool, maxLines int, errorOnly bool) (string, bool) {
 	needle := query
 	if !caseSensitive {
 		needle = strings.ToLower(query)
@@ -231,12 +249,12 @@ func filterLines(content, query string, caseSensitive bool, maxLines int, errorO
 		if errorOnly && !matchesErrorPattern(hay) {
 			continue
 		}
-		filtered = append(filtered, line)
 		if len(filtered) >= maxLines {
-			break
+			return strings.Join(filtered, "\n"), true
 		}
+		filtered = append(filtered, line)
 	}
-	return strings.Join(filtered, "\n")
+	return strings.Join(filtered, "\n"), false
 }
 
 func matchesErrorPattern(line string) bool {
@@ -1,27 +1,40 @@
 package plugins
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/metrics"
 	pluginapi "github.com/edgeopslabs/nexus/pkg/plugins"
 	"github.com/edgeopslabs/nexus/pkg/registry"
+	"github.com/edgeopslabs/nexus/pkg/tracing"
 	"github.com/edgeopslabs/nexus/pkg/types"
 	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-const moduleName = "plugins"
+const (
+	moduleName      = "plugins"
+	pluginWaitDelay = 5 * time.Second
+)
 
 type Module struct {
 	cfg       *config.Config
 	manifests []pluginapi.Manifest
 	tools     map[string]pluginTool
+
+	mu      sync.Mutex
+	running map[*exec.Cmd]struct{}
 }
 
 type pluginTool struct {
@@ -31,7 +44,8 @@ type pluginTool struct {
 
 func New() *Module {
 	return &Module{
-		tools: make(map[string]pluginTool),
+		tools:   make(map[string]pluginTool),
+		running: make(map[*exec.Cmd]struct{}),
 	}
 }
 
@@ -45,6 +59,9 @@ func (m *Module) Enabled(cfg *config.Config) bool {
 
 func (m *Module) Init(cfg *config.Config) error {
 	m.cfg = cfg
+	// Init also runs on config reload; start from an empty tool set.
+	m.manifests = nil
+	m.tools = make(map[string]pluginTool)
 	if !cfg.Modules.Plugins.Enabled {
 		slog.Info("plugins module disabled by config")
 		return nil
@@ -52,8 +69,7 @@ func (m *Module) Init(cfg *config.Config) error {
 
 	manifests, err := pluginapi.LoadManifests(cfg.Modules.Plugins.Dir)
 	if err != nil {
-		slog.Warn("failed to load plugin manifests", "error", err)
-		return nil
+		return fmt.Errorf("failed to load plugin manifests: %w", err)
 	}
 	m.manifests = manifests
 
@@ -69,14 +85,20 @@ func (m *Module) Init(cfg *config.Config) error {
 	return nil
 }
 
+// Priority initializes plugins after the built-in modules. Tools are listed
+// in module init order, so plugin tools follow the built-in ones in
+// tools/list and /tools.
+func (m *Module) Priority() int {
+	return 100
+}
+
 func (m *Module) GetTools() []mcp.Tool {
 	if m.cfg == nil || !m.cfg.Modules.Plugins.Enabled {
 		return nil
 	}
 	var tools []mcp.Tool
 	for name, pt := range m.tools {
-		tool := buildToolSchema(name, pt.tool)
-		tools = append(tools, tool)
+		tools = append(tools, buildToolSchema(name, pt.tool))
 	}
 	return tools
 }
@@ -120,12 +142,65 @@ func (m *Module) HandleCall(ctx context.Context, name string, args map[string]in
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
 	}
 	cmd.Stdin = strings.NewReader(string(data))
+	cmd.WaitDelay = pluginWaitDelay
 
-	output, err := cmd.CombinedOutput()
+	output, err := m.run(ctx, pt.manifest.Metadata.Name, cmd)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("plugin error: %v: %s", err, strings.TrimSpace(string(output)))), nil
 	}
-	return mcp.NewToolResultText(trimOutput(string(output), m.cfg.Modules.Plugins.MaxBytes)), nil
+	text, truncated := trimOutput(string(output), m.cfg.Modules.Plugins.MaxBytes)
+	if truncated {
+		metrics.Truncated(moduleName, name)
+	}
+	return mcp.NewToolResultText(text), nil
+}
+
+func (m *Module) run(ctx context.Context, plugin string, cmd *exec.Cmd) (_ []byte, err error) {
+	ctx, span := tracing.Start(ctx, "plugin "+plugin, attribute.String("plugin.command", cmd.Path))
+	defer func() { tracing.End(span, err) }()
+	cmd.Env = append(cmd.Env, tracing.Env(ctx)...)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.running[cmd] = struct{}{}
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, cmd)
+		m.mu.Unlock()
+	}()
+
+	err = cmd.Wait()
+	metrics.PluginExited(plugin, cmd.ProcessState.ExitCode())
+	span.SetAttributes(attribute.Int("plugin.exit_code", cmd.ProcessState.ExitCode()))
+	return output.Bytes(), err
+}
+
+// Close kills plugin subprocesses that outlived their call context.
+func (m *Module) Close(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for cmd := range m.running {
+		if cmd.Process != nil {
+			slog.Warn("killing plugin subprocess", "pid", cmd.Process.Pid, "path", cmd.Path)
+			_ = cmd.Process.Kill()
+		}
+	}
+	return nil
+}
+
+// HealthCheck verifies the plugins directory is still readable.
+func (m *Module) HealthCheck(_ context.Context) error {
+	if _, err := os.ReadDir(m.cfg.Modules.Plugins.Dir); err != nil {
+		return fmt.Errorf("plugins dir unreadable: %w", err)
+	}
+	return nil
 }
 
 func buildToolSchema(name string, spec pluginapi.ToolSpec) mcp.Tool {
@@ -171,19 +246,24 @@ func pluginToolName(pluginName, toolName string) string {
 	return fmt.Sprintf("plugin/%s/%s", pluginName, toolName)
 }
 
-func trimOutput(output string, maxBytes int) string {
+func trimOutput(output string, maxBytes int) (string, bool) {
 	if maxBytes <= 0 {
-		return output
+		return output, false
 	}
 	data := []byte(output)
 	if len(data) <= maxBytes {
-		return output
+		return output, false
 	}
-	return string(data[:maxBytes]) + "\n... truncated"
+	return string(data[:maxBytes]) + "\n... truncated", true
 }
 
 func init() {
 	registry.Register(moduleName, New())
 }
 
-var _ types.NexusModule = (*Module)(nil)
+var (
+	_ types.NexusModule   = (*Module)(nil)
+	_ types.Closer        = (*Module)(nil)
+	_ types.HealthChecker = (*Module)(nil)
+	_ types.Prioritized   = (*Module)(nil)
+)
@@ -5,13 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/edgeopslabs/nexus/pkg/config"
 	"github.com/edgeopslabs/nexus/pkg/registry"
+	"github.com/edgeopslabs/nexus/pkg/tracing"
 	"github.com/edgeopslabs/nexus/pkg/types"
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -22,18 +25,37 @@ const (
 )
 
 type Module struct {
-	cfg    *config.Config
-	client *http.Client
+	cfg      *config.Config
+	instance string                  // "" unless modules.prometheus.instances names servers
+	settings config.PrometheusConfig // the section, resolved for the instance
+	client   *http.Client
 }
 
 func New() *Module {
+	return newModule("")
+}
+
+func newModule(instance string) *Module {
 	return &Module{
-		client: &http.Client{Timeout: 15 * time.Second},
+		instance: instance,
+		client: &http.Client{
+			Timeout:   15 * time.Second,
+			Transport: tracing.Transport(nil, types.InstanceName(moduleName, instance)),
+		},
 	}
 }
 
 func (m *Module) Name() string {
-	return moduleName
+	return types.InstanceName(moduleName, m.instance)
+}
+
+// Instances lists the configured Prometheus servers, one module each.
+func (m *Module) Instances(cfg *config.Config) []string {
+	return slices.Sorted(maps.Keys(cfg.Modules.Prometheus.Instances))
+}
+
+func (m *Module) NewInstance(name string) types.NexusModule {
+	return newModule(name)
 }
 
 func (m *Module) Enabled(cfg *config.Config) bool {
@@ -42,12 +64,18 @@ func (m *Module) Enabled(cfg *config.Config) bool {
 
 func (m *Module) Init(cfg *config.Config) error {
 	m.cfg = cfg
+	m.settings = cfg.Modules.Prometheus.Instance(m.instance)
 	if !cfg.Modules.Prometheus.Enabled {
 		slog.Info("prometheus module disabled by config")
 	}
 	return nil
 }
 
+func (m *Module) Close(_ context.Context) error {
+	m.client.CloseIdleConnections()
+	return nil
+}
+
 func (m *Module) GetTools() []mcp.Tool {
 	if m.cfg == nil || !m.cfg.Modules.Prometheus.Enabled {
 		return nil
@@ -82,7 +110,7 @@ func (m *Module) handleQueryMetric(ctx context.Context, args map[string]interfac
 		return mcp.NewToolResultError("query is required"), nil
 	}
 
-	endpoint, err := buildQueryURL(m.cfg.Modules.Prometheus.URL, query)
+	endpoint, err := buildQueryURL(m.settings.URL, query)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("invalid prometheus url: %v", err)), nil
 	}
@@ -117,6 +145,27 @@ func (m *Module) handleQueryMetric(ctx context.Context, args map[string]interfac
 	return mcp.NewToolResultText(result), nil
 }
 
+// HealthCheck probes Prometheus' readiness endpoint.
+func (m *Module) HealthCheck(ctx context.Context) error {
+	endpoint, err := buildURL(m.settings.URL, "/-/ready")
+	if err != nil {
+		return fmt.Errorf("invalid prometheus url: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("prometheus returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 type prometheusResponse struct {
 	Status string `json:"status"`
 	Error  string `json:"error,omitempty"`
@@ -132,6 +181,23 @@ type prometheusVectorResult struct {
 }
 
 func buildQueryURL(baseURL, query string) (string, error) {
+	endpoint, err := buildURL(baseURL, "/api/v1/query")
+	if err != nil {
+		return "", err
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	q := parsed.Query()
+	q.Set("query", query)
+	parsed.RawQuery = q.Encode()
+	return parsed.String(), nil
+}
+
+// buildURL appends path to the configured base URL, keeping any path prefix
+// (e.g. behind a reverse proxy).
+func buildURL(baseURL, path string) (string, error) {
 	trimmed := strings.TrimSpace(baseURL)
 	if trimmed == "" {
 		return "", fmt.Errorf("base url is empty")
@@ -144,12 +210,7 @@ func buildQueryURL(baseURL, query string) (string, error) {
 		return "", fmt.Errorf("base url must include scheme and host")
 	}
 
-	path := strings.TrimRight(parsed.Path, "/")
-	parsed.Path = path + "/api/v1/query"
-
-	q := parsed.Query()
-	q.Set("query", query)
-	parsed.RawQuery = q.Encode()
+	parsed.Path = strings.TrimRight(parsed.Path, "/") + path
 	return parsed.String(), nil
 }
 
@@ -189,4 +250,9 @@ func init() {
 	registry.Register(moduleName, New())
 }
 
-var _ types.NexusModule = (*Module)(nil)
+var (
+	_ types.NexusModule   = (*Module)(nil)
+	_ types.Closer        = (*Module)(nil)
+	_ types.HealthChecker = (*Module)(nil)
+	_ types.MultiInstance = (*Module)(nil)
+)
@@ -2,14 +2,21 @@ package plugins
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/edgeopslabs/nexus/pkg/schema"
+	"github.com/invopop/jsonschema"
 	"gopkg.in/yaml.v3"
 )
 
 const ManifestName = "nexus.yaml"
 
+// LoadManifests reads the manifest of every plugin directory under dir.
+// Manifests that cannot be read or do not validate are skipped with a
+// warning; only an unreadable dir is an error.
 func LoadManifests(dir string) ([]Manifest, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
@@ -26,9 +33,11 @@ func LoadManifests(dir string) ([]Manifest, error) {
 		if err != nil {
 			continue
 		}
-		var manifest Manifest
-		if err := yaml.Unmarshal(data, &manifest); err != nil {
-			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		// One broken or newer manifest must not take the other plugins down.
+		manifest, err := parseManifest(path, data)
+		if err != nil {
+			slog.Warn("skipping plugin manifest", "path", path, "error", err)
+			continue
 		}
 		if manifest.Metadata.Name == "" {
 			manifest.Metadata.Name = entry.Name()
@@ -37,3 +46,28 @@ func LoadManifests(dir string) ([]Manifest, error) {
 	}
 	return manifests, nil
 }
+
+// Schema returns the JSON Schema of a plugin manifest, for editors and for
+// "nexus schema manifest". LoadManifests checks every manifest against it.
+func Schema() *jsonschema.Schema {
+	return schema.Reflect(&Manifest{}, "Nexus plugin manifest")
+}
+
+func parseManifest(path string, data []byte) (Manifest, error) {
+	var manifest Manifest
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return manifest, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if issues := schema.Validate(Schema(), &root); len(issues) > 0 {
+		lines := make([]string, len(issues))
+		for i, issue := range issues {
+			lines[i] = path + ":" + issue.String()
+		}
+		return manifest, fmt.Errorf("invalid manifest:\n%s", strings.Join(lines, "\n"))
+	}
+	if err := root.Decode(&manifest); err != nil {
+		return manifest, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return manifest, nil
+}
@@ -1,8 +1,8 @@
 package plugins
 
 type Manifest struct {
-	APIVersion string   `yaml:"apiVersion"`
-	Kind       string   `yaml:"kind"`
+	APIVersion string   `yaml:"apiVersion" jsonschema:"enum=nexus/v1alpha1"`
+	Kind       string   `yaml:"kind" jsonschema:"enum=ContextServer"`
 	Metadata   Metadata `yaml:"metadata"`
 	Spec       Spec     `yaml:"spec"`
 }
@@ -15,7 +15,7 @@ type Metadata struct {
 }
 
 type Spec struct {
-	Command      string            `yaml:"command"`
+	Command      string            `yaml:"command" jsonschema:"required"`
 	Args         []string          `yaml:"args"`
 	Env          map[string]string `yaml:"env"`
 	Capabilities Capabilities      `yaml:"capabilities"`
@@ -26,15 +26,15 @@ type Capabilities struct {
 }
 
 type ToolSpec struct {
-	Name        string    `yaml:"name"`
+	Name        string    `yaml:"name" jsonschema:"required"`
 	Description string    `yaml:"description"`
 	ReadOnly    bool      `yaml:"read_only"`
 	Args        []ArgSpec `yaml:"args"`
 }
 
 type ArgSpec struct {
-	Name        string `yaml:"name"`
-	Type        string `yaml:"type"` // string, number, integer, boolean
+	Name        string `yaml:"name" jsonschema:"required"`
+	Type        string `yaml:"type" jsonschema:"enum=string,enum=number,enum=float,enum=integer,enum=int,enum=boolean,enum=bool"`
 	Required    bool   `yaml:"required"`
 	Description string `yaml:"description"`
 }
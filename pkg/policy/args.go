@@ -0,0 +1,138 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+type argRule struct {
+	index    int
+	tools    []string
+	effect   Decision
+	matchers map[string]argMatcher
+}
+
+type argMatcher struct {
+	glob      string
+	regex     *regexp.Regexp
+	enum      []string
+	cleanPath bool
+}
+
+func compileArgRules(rules []config.ArgRule) ([]argRule, error) {
+	compiled := make([]argRule, 0, len(rules))
+	for i, rule := range rules {
+		if len(rule.Tools) == 0 {
+			return nil, fmt.Errorf("arg_rules[%d]: tools is required", i)
+		}
+		for _, pattern := range rule.Tools {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("arg_rules[%d]: invalid tool pattern %q: %w", i, pattern, err)
+			}
+		}
+		effect, err := parseEffect(rule.Effect)
+		if err != nil {
+			return nil, fmt.Errorf("arg_rules[%d]: %w", i, err)
+		}
+		if len(rule.Args) == 0 {
+			return nil, fmt.Errorf("arg_rules[%d]: args is required", i)
+		}
+
+		matchers := make(map[string]argMatcher, len(rule.Args))
+		for key, spec := range rule.Args {
+			if spec.Glob == "" && spec.Regex == "" && len(spec.Enum) == 0 {
+				return nil, fmt.Errorf("arg_rules[%d].args.%s: one of glob, regex or enum is required", i, key)
+			}
+			// A pattern for absolute paths is matched against the cleaned
+			// value, so "//" or ".." segments cannot step around it.
+			cleanPath := spec.CleanPath || strings.HasPrefix(spec.Glob, "/") || strings.HasPrefix(spec.Regex, "/")
+			matcher := argMatcher{glob: spec.Glob, enum: spec.Enum, cleanPath: cleanPath}
+			if spec.Glob != "" {
+				if _, err := path.Match(spec.Glob, ""); err != nil {
+					return nil, fmt.Errorf("arg_rules[%d].args.%s: invalid glob %q: %w", i, key, spec.Glob, err)
+				}
+			}
+			if spec.Regex != "" {
+				re, err := regexp.Compile("^(?:" + spec.Regex + ")$")
+				if err != nil {
+					return nil, fmt.Errorf("arg_rules[%d].args.%s: invalid regex: %w", i, key, err)
+				}
+				matcher.regex = re
+			}
+			matchers[key] = matcher
+		}
+		compiled = append(compiled, argRule{index: i, tools: rule.Tools, effect: effect, matchers: matchers})
+	}
+	return compiled, nil
+}
+
+func parseEffect(value string) (Decision, error) {
+	switch strings.ToLower(value) {
+	case "allow":
+		return Allow, nil
+	case "deny":
+		return Deny, nil
+	case "confirm":
+		return Confirm, nil
+	default:
+		return Allow, fmt.Errorf("effect must be allow, deny or confirm, got %q", value)
+	}
+}
+
+func (r argRule) matchesArgs(args map[string]interface{}) bool {
+	for key, matcher := range r.matchers {
+		value, ok := args[key]
+		if !ok || value == nil {
+			return false
+		}
+		if !matcher.match(argString(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m argMatcher) match(value string) bool {
+	if m.cleanPath {
+		value = path.Clean(value)
+	}
+	if m.glob != "" {
+		if matched, _ := path.Match(m.glob, value); !matched {
+			return false
+		}
+	}
+	if m.regex != nil && !m.regex.MatchString(value) {
+		return false
+	}
+	if len(m.enum) > 0 {
+		found := false
+		for _, allowed := range m.enum {
+			if value == allowed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func argString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,129 @@
+package policy
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/google/cel-go/cel"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type celRule struct {
+	index   int
+	name    string
+	effect  Decision
+	reason  string
+	program cel.Program
+}
+
+func newCELEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("module", cel.StringType),
+		cel.Variable("tool", cel.StringType),
+		cel.Variable("args", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("identity", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("time", cel.TimestampType),
+		cel.Variable("annotations", cel.MapType(cel.StringType, cel.DynType)),
+	)
+}
+
+func compileRules(rules []config.Rule) ([]celRule, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	env, err := newCELEnv()
+	if err != nil {
+		return nil, fmt.Errorf("cel environment: %w", err)
+	}
+
+	compiled := make([]celRule, 0, len(rules))
+	for i, rule := range rules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rules[%d]", i)
+		}
+		effect, err := parseEffect(rule.Effect)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if rule.Expression == "" {
+			return nil, fmt.Errorf("%s: expression is required", name)
+		}
+		ast, issues := env.Compile(rule.Expression)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("%s: %w", name, issues.Err())
+		}
+		if ast.OutputType() != cel.BoolType {
+			return nil, fmt.Errorf("%s: expression must evaluate to bool, got %s", name, ast.OutputType())
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		reason := rule.Reason
+		if reason == "" {
+			reason = "matched rule " + name
+		}
+		compiled = append(compiled, celRule{index: i, name: name, effect: effect, reason: reason, program: program})
+	}
+	return compiled, nil
+}
+
+// matches evaluates the rule. Evaluation errors (typically a missing args key
+// without a has() guard) resolve conservatively: deny and confirm rules are
+// treated as matching, allow rules as not matching.
+func (r celRule) matches(activation map[string]any) bool {
+	out, _, err := r.program.Eval(activation)
+	if err != nil {
+		slog.Warn("policy rule evaluation failed", "rule", r.name, "error", err)
+		return r.effect != Allow
+	}
+	matched, ok := out.Value().(bool)
+	return ok && matched
+}
+
+func celActivation(req Request) map[string]any {
+	args := req.Args
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	identity := map[string]any{"subject": "", "method": "", "claims": map[string]any{}}
+	if req.Identity != nil {
+		identity["subject"] = req.Identity.Subject
+		identity["method"] = req.Identity.Method
+		if req.Identity.Claims != nil {
+			identity["claims"] = req.Identity.Claims
+		}
+	}
+	now := req.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return map[string]any{
+		"module":      req.Module,
+		"tool":        req.Tool,
+		"args":        args,
+		"identity":    identity,
+		"time":        now,
+		"annotations": annotationMap(req.Annotations),
+	}
+}
+
+func annotationMap(annotations mcp.ToolAnnotation) map[string]any {
+	out := map[string]any{"title": annotations.Title}
+	if annotations.ReadOnlyHint != nil {
+		out["readOnly"] = *annotations.ReadOnlyHint
+	}
+	if annotations.DestructiveHint != nil {
+		out["destructive"] = *annotations.DestructiveHint
+	}
+	if annotations.IdempotentHint != nil {
+		out["idempotent"] = *annotations.IdempotentHint
+	}
+	if annotations.OpenWorldHint != nil {
+		out["openWorld"] = *annotations.OpenWorldHint
+	}
+	return out
+}
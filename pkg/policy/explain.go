@@ -0,0 +1,76 @@
+package policy
+
+import "fmt"
+
+// Explanation is a Verdict together with the config entry that produced it.
+type Explanation struct {
+	Verdict
+	// Matched names the deciding entry, e.g. `policy.deny_tools[0] "k8s_*"`
+	// or `server.safe_mode (keyword "scale" in tool name)`. It is empty when
+	// nothing matched and the default allow applied.
+	Matched string
+}
+
+// Explain evaluates req exactly like EvaluateCall and reports which entry
+// decided it.
+func (p *Policy) Explain(req Request) Explanation {
+	decision, matched := p.evaluateNames(req.Module, req.Tool, req.Annotations)
+	if decision == Deny {
+		return Explanation{
+			Verdict: Verdict{Decision: Deny, Reason: "not permitted by module/tool lists or safe mode"},
+			Matched: matched,
+		}
+	}
+
+	for _, rule := range p.argRules {
+		if !matchesAnyTool(rule.tools, req.Module, req.Tool) {
+			continue
+		}
+		entry := fmt.Sprintf("policy.arg_rules[%d]", rule.index)
+		matchedArgs := rule.matchesArgs(req.Args)
+		switch rule.effect {
+		case Allow:
+			if !matchedArgs {
+				return Explanation{
+					Verdict: Verdict{Decision: Deny, Reason: fmt.Sprintf("arguments not allowed by arg_rules[%d]", rule.index)},
+					Matched: entry,
+				}
+			}
+		case Deny:
+			if matchedArgs {
+				return Explanation{
+					Verdict: Verdict{Decision: Deny, Reason: fmt.Sprintf("arguments denied by arg_rules[%d]", rule.index)},
+					Matched: entry,
+				}
+			}
+		case Confirm:
+			if matchedArgs {
+				decision = Confirm
+				matched = entry
+			}
+		}
+	}
+
+	if len(p.rules) > 0 {
+		activation := celActivation(req)
+		for _, rule := range p.rules {
+			if rule.matches(activation) {
+				// A rule may tighten the decision so far but never loosen
+				// it; a looser rule is skipped in favour of later ones.
+				if severity(rule.effect) < severity(decision) {
+					continue
+				}
+				return Explanation{
+					Verdict: Verdict{Decision: rule.effect, Reason: rule.reason},
+					Matched: fmt.Sprintf("policy.rules[%d] %q", rule.index, rule.name),
+				}
+			}
+		}
+	}
+	return Explanation{Verdict: Verdict{Decision: decision}, Matched: matched}
+}
+
+// ParseDecision converts "allow", "deny" or "confirm" to a Decision.
+func ParseDecision(value string) (Decision, error) {
+	return parseEffect(value)
+}
@@ -1,10 +1,15 @@
 package policy
 
 import (
+	"fmt"
 	"path"
 	"strings"
+	"time"
 
+	"github.com/edgeopslabs/nexus/pkg/auth"
 	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
 )
 
 type Decision int
@@ -15,68 +20,194 @@ const (
 	Confirm
 )
 
+func (d Decision) String() string {
+	switch d {
+	case Allow:
+		return "allow"
+	case Deny:
+		return "deny"
+	case Confirm:
+		return "confirm"
+	default:
+		return "unknown"
+	}
+}
+
+// severity orders decisions from allow to deny, for rules that may only
+// tighten a decision.
+func severity(d Decision) int {
+	switch d {
+	case Deny:
+		return 2
+	case Confirm:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Request describes a single tool call for EvaluateCall.
+type Request struct {
+	Module      string
+	Tool        string
+	Args        map[string]interface{}
+	Identity    *auth.Identity
+	Annotations mcp.ToolAnnotation
+	Time        time.Time
+}
+
+type Verdict struct {
+	Decision Decision
+	Reason   string
+}
+
 type Policy struct {
 	cfg      config.PolicyConfig
 	safeMode bool
+	argRules []argRule
+	rules    []celRule
 }
 
-func New(cfg config.PolicyConfig, safeMode bool) *Policy {
-	return &Policy{cfg: cfg, safeMode: safeMode}
+// New compiles arg_rules and CEL rules up front so that invalid policy
+// fails at startup rather than silently never matching.
+func New(cfg config.PolicyConfig, safeMode bool) (*Policy, error) {
+	argRules, err := compileArgRules(cfg.ArgRules)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := compileRules(cfg.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("policy rules: %w", err)
+	}
+	return &Policy{cfg: cfg, safeMode: safeMode, argRules: argRules, rules: rules}, nil
 }
 
+// Evaluate decides on names alone; safe mode falls back to keyword matching
+// since no annotations are available. Prefer EvaluateTool when the tool
+// definition is at hand.
 func (p *Policy) Evaluate(module, tool string) Decision {
-	if p.safeMode && isSensitiveTool(tool) {
-		return Deny
+	decision, _ := p.evaluateNames(module, tool, mcp.ToolAnnotation{})
+	return decision
+}
+
+func (p *Policy) EvaluateTool(module string, tool mcp.Tool) Decision {
+	decision, _ := p.evaluateNames(module, tool.Name, tool.Annotations)
+	return decision
+}
+
+// evaluateNames applies safe mode and the module/tool lists, returning the
+// config entry that decided the outcome ("" when nothing matched).
+func (p *Policy) evaluateNames(module, tool string, annotations mcp.ToolAnnotation) (Decision, string) {
+	if p.safeMode {
+		if mutating, why := mutatingReason(tool, annotations); mutating {
+			return Deny, "server.safe_mode (" + why + ")"
+		}
 	}
 
-	if matchesAny(p.cfg.DenyModules, module) || matchesAnyTool(p.cfg.DenyTools, module, tool) {
-		return Deny
+	if i := matchIndex(p.cfg.DenyModules, module); i >= 0 {
+		return Deny, fmt.Sprintf("policy.deny_modules[%d] %q", i, p.cfg.DenyModules[i])
+	}
+	if i := matchToolIndex(p.cfg.DenyTools, module, tool); i >= 0 {
+		return Deny, fmt.Sprintf("policy.deny_tools[%d] %q", i, p.cfg.DenyTools[i])
 	}
 
 	if hasAllowList(p.cfg) && !matchesAny(p.cfg.AllowModules, module) && !matchesAnyTool(p.cfg.AllowTools, module, tool) {
-		return Deny
+		return Deny, "policy.allow_modules/allow_tools (not listed)"
 	}
 
-	if matchesAnyTool(p.cfg.ConfirmTools, module, tool) {
-		return Confirm
+	if i := matchToolIndex(p.cfg.ConfirmTools, module, tool); i >= 0 {
+		return Confirm, fmt.Sprintf("policy.confirm_tools[%d] %q", i, p.cfg.ConfirmTools[i])
 	}
 
-	return Allow
+	return Allow, ""
+}
+
+// EvaluateCall applies the name-based decision, then any arg_rules that
+// target the tool, then the first matching CEL rule that is at least as
+// strict.
+func (p *Policy) EvaluateCall(req Request) Verdict {
+	return p.Explain(req).Verdict
 }
 
 func hasAllowList(cfg config.PolicyConfig) bool {
 	return len(cfg.AllowModules) > 0 || len(cfg.AllowTools) > 0
 }
 
+// MatchTool reports whether any glob matches tool or "module/tool", the
+// pattern syntax used throughout the policy config. A module instance
+// matches as "prometheus@eu/tool" and as "prometheus/tool".
+func MatchTool(patterns []string, module, tool string) bool {
+	return matchesAnyTool(patterns, module, tool)
+}
+
 func matchesAny(patterns []string, value string) bool {
-	for _, pattern := range patterns {
-		if matched, _ := path.Match(pattern, value); matched {
-			return true
+	return matchIndex(patterns, value) >= 0
+}
+
+func matchesAnyTool(patterns []string, module, tool string) bool {
+	return matchToolIndex(patterns, module, tool) >= 0
+}
+
+func matchIndex(patterns []string, module string) int {
+	for i, pattern := range patterns {
+		for _, name := range moduleNames(module) {
+			if matched, _ := path.Match(pattern, name); matched {
+				return i
+			}
 		}
 	}
-	return false
+	return -1
 }
 
-func matchesAnyTool(patterns []string, module, tool string) bool {
-	qualified := module + "/" + tool
-	for _, pattern := range patterns {
+func matchToolIndex(patterns []string, module, tool string) int {
+	for i, pattern := range patterns {
 		if matched, _ := path.Match(pattern, tool); matched {
-			return true
+			return i
 		}
-		if matched, _ := path.Match(pattern, qualified); matched {
-			return true
+		for _, name := range moduleNames(module) {
+			if matched, _ := path.Match(pattern, name+"/"+tool); matched {
+				return i
+			}
 		}
 	}
-	return false
+	return -1
+}
+
+// moduleNames lists the names module patterns are matched against: an
+// instance such as "prometheus@eu" also matches as its module.
+func moduleNames(module string) []string {
+	if base, instance := types.SplitInstance(module); instance != "" {
+		return []string{module, base}
+	}
+	return []string{module}
+}
+
+// mutatingReason trusts the tool's ReadOnlyHint, then a DestructiveHint of
+// true, and only sniffs the name for verbs when neither settles it. The
+// string says which of those decided. mcp.NewTool always sets ReadOnlyHint
+// (false unless the tool opts in), so the name fallback only applies to
+// Evaluate and to hand-built mcp.Tool values, such as the definitions
+// policy explain and policy test assume for tools that are not loaded.
+func mutatingReason(tool string, annotations mcp.ToolAnnotation) (bool, string) {
+	if annotations.ReadOnlyHint != nil {
+		return !*annotations.ReadOnlyHint, fmt.Sprintf("readOnlyHint=%t", *annotations.ReadOnlyHint)
+	}
+	if annotations.DestructiveHint != nil && *annotations.DestructiveHint {
+		return true, "destructiveHint=true"
+	}
+	if keyword := sensitiveKeyword(tool); keyword != "" {
+		return true, fmt.Sprintf("keyword %q in tool name", keyword)
+	}
+	return false, ""
 }
 
-func isSensitiveTool(tool string) bool {
+func sensitiveKeyword(tool string) string {
 	lower := strings.ToLower(tool)
 	sensitive := []string{"delete", "update", "scale", "write", "create", "apply", "patch"}
 	for _, keyword := range sensitive {
 		if strings.Contains(lower, keyword) {
-			return true
+			return keyword
 		}
 	}
-	return false
+	return ""
 }
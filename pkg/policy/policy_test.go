@@ -3,14 +3,25 @@ package policy
 import (
 	"testing"
 
+	"github.com/edgeopslabs/nexus/pkg/auth"
 	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/mark3labs/mcp-go/mcp"
 )
 
+func mustNew(t *testing.T, cfg config.PolicyConfig, safeMode bool) *Policy {
+	t.Helper()
+	p, err := New(cfg, safeMode)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	return p
+}
+
 func TestPolicyDenyOverrides(t *testing.T) {
 	cfg := config.PolicyConfig{
 		DenyTools: []string{"k8s_list_pods"},
 	}
-	p := New(cfg, false)
+	p := mustNew(t, cfg, false)
 	if p.Evaluate("kubernetes", "k8s_list_pods") != Deny {
 		t.Fatalf("expected deny")
 	}
@@ -20,7 +31,7 @@ func TestPolicyAllowList(t *testing.T) {
 	cfg := config.PolicyConfig{
 		AllowTools: []string{"prometheus_query_metric"},
 	}
-	p := New(cfg, false)
+	p := mustNew(t, cfg, false)
 	if p.Evaluate("kubernetes", "k8s_list_pods") != Deny {
 		t.Fatalf("expected deny when allowlist does not match")
 	}
@@ -33,15 +44,352 @@ func TestPolicyConfirm(t *testing.T) {
 	cfg := config.PolicyConfig{
 		ConfirmTools: []string{"kubernetes/k8s_list_pods"},
 	}
-	p := New(cfg, false)
+	p := mustNew(t, cfg, false)
 	if p.Evaluate("kubernetes", "k8s_list_pods") != Confirm {
 		t.Fatalf("expected confirm")
 	}
 }
 
+func TestPolicyPatternsTargetModuleInstances(t *testing.T) {
+	cfg := config.PolicyConfig{
+		DenyModules:  []string{"prometheus@us"},
+		ConfirmTools: []string{"prometheus/*"},
+	}
+	p := mustNew(t, cfg, false)
+	if p.Evaluate("prometheus@us", "prometheus_query_metric") != Deny {
+		t.Fatalf("expected instance pattern to deny its instance")
+	}
+	if p.Evaluate("prometheus@eu", "prometheus_query_metric") != Confirm {
+		t.Fatalf("expected module pattern to match every instance")
+	}
+}
+
 func TestSafeModeBlocksSensitive(t *testing.T) {
-	p := New(config.PolicyConfig{}, true)
+	p := mustNew(t, config.PolicyConfig{}, true)
 	if p.Evaluate("kubernetes", "k8s_delete_pod") != Deny {
 		t.Fatalf("expected deny for sensitive tool in safe mode")
 	}
 }
+
+func TestArgRuleAllowRestrictsArguments(t *testing.T) {
+	cfg := config.PolicyConfig{
+		ArgRules: []config.ArgRule{{
+			Tools:  []string{"k8s_get_logs"},
+			Effect: "allow",
+			Args:   map[string]config.ArgMatcher{"namespace": {Glob: "team-a-*"}},
+		}},
+	}
+	p := mustNew(t, cfg, false)
+	if p.EvaluateCall(Request{Module: "kubernetes", Tool: "k8s_get_logs", Args: map[string]interface{}{"namespace": "team-a-api"}}).Decision != Allow {
+		t.Fatalf("expected allow for matching namespace")
+	}
+	if p.EvaluateCall(Request{Module: "kubernetes", Tool: "k8s_get_logs", Args: map[string]interface{}{"namespace": "kube-system"}}).Decision != Deny {
+		t.Fatalf("expected deny for non-matching namespace")
+	}
+	if p.EvaluateCall(Request{Module: "kubernetes", Tool: "k8s_get_logs", Args: map[string]interface{}{}}).Decision != Deny {
+		t.Fatalf("expected deny when argument is missing")
+	}
+	if p.EvaluateCall(Request{Module: "kubernetes", Tool: "k8s_list_pods", Args: map[string]interface{}{"namespace": "kube-system"}}).Decision != Allow {
+		t.Fatalf("expected rule to apply only to targeted tools")
+	}
+}
+
+func TestArgRuleDenyMatchesCleanedPath(t *testing.T) {
+	cfg := config.PolicyConfig{
+		ArgRules: []config.ArgRule{{
+			Tools:  []string{"logs/logs_*"},
+			Effect: "deny",
+			Args:   map[string]config.ArgMatcher{"path": {Regex: "/var/log/auth.*", CleanPath: true}},
+		}},
+	}
+	p := mustNew(t, cfg, false)
+	if p.EvaluateCall(Request{Module: "logs", Tool: "logs_tail", Args: map[string]interface{}{"path": "/var/log/../log/auth.log"}}).Decision != Deny {
+		t.Fatalf("expected deny for auth log path")
+	}
+	if p.EvaluateCall(Request{Module: "logs", Tool: "logs_tail", Args: map[string]interface{}{"path": "/var/log/syslog"}}).Decision != Allow {
+		t.Fatalf("expected allow for other paths")
+	}
+}
+
+func TestArgRuleAbsolutePathPatternsMatchCleanedPath(t *testing.T) {
+	cfg := config.PolicyConfig{
+		ArgRules: []config.ArgRule{
+			{Tools: []string{"logs/logs_tail"}, Effect: "deny", Args: map[string]config.ArgMatcher{"path": {Glob: "/var/log/auth*"}}},
+			{Tools: []string{"logs/logs_search"}, Effect: "deny", Args: map[string]config.ArgMatcher{"path": {Regex: "/var/log/auth.*"}}},
+		},
+	}
+	p := mustNew(t, cfg, false)
+	for _, tool := range []string{"logs_tail", "logs_search"} {
+		for _, bypass := range []string{"/var/log//auth.log", "/var/log/x/../auth.log", "/var/./log/auth.log"} {
+			if p.EvaluateCall(Request{Module: "logs", Tool: tool, Args: map[string]interface{}{"path": bypass}}).Decision != Deny {
+				t.Fatalf("%s: expected deny for %q", tool, bypass)
+			}
+		}
+		if p.EvaluateCall(Request{Module: "logs", Tool: tool, Args: map[string]interface{}{"path": "/var/log/syslog"}}).Decision != Allow {
+			t.Fatalf("%s: expected allow for other paths", tool)
+		}
+	}
+}
+
+func TestArgRuleConfirmEnum(t *testing.T) {
+	cfg := config.PolicyConfig{
+		ArgRules: []config.ArgRule{{
+			Tools:  []string{"k8s_get_logs"},
+			Effect: "confirm",
+			Args:   map[string]config.ArgMatcher{"tail_lines": {Enum: []string{"500"}}},
+		}},
+	}
+	p := mustNew(t, cfg, false)
+	if p.EvaluateCall(Request{Module: "kubernetes", Tool: "k8s_get_logs", Args: map[string]interface{}{"tail_lines": float64(500)}}).Decision != Confirm {
+		t.Fatalf("expected confirm for enum match")
+	}
+	if p.EvaluateCall(Request{Module: "kubernetes", Tool: "k8s_get_logs", Args: map[string]interface{}{"tail_lines": float64(100)}}).Decision != Allow {
+		t.Fatalf("expected allow outside enum")
+	}
+}
+
+func TestArgRuleInvalidRegexFailsNew(t *testing.T) {
+	cfg := config.PolicyConfig{
+		ArgRules: []config.ArgRule{{
+			Tools:  []string{"k8s_get_logs"},
+			Effect: "deny",
+			Args:   map[string]config.ArgMatcher{"namespace": {Regex: "("}},
+		}},
+	}
+	if _, err := New(cfg, false); err == nil {
+		t.Fatalf("expected error for invalid regex")
+	}
+}
+
+func TestCELRuleFirstMatchWins(t *testing.T) {
+	readOnly := true
+	cfg := config.PolicyConfig{
+		Rules: []config.Rule{
+			{
+				Name:       "oncall-prod",
+				Expression: `identity.subject == "oncall" && has(args.namespace) && args.namespace == "prod"`,
+				Effect:     "confirm",
+				Reason:     "prod access needs confirmation",
+			},
+			{
+				Name:       "no-prod",
+				Expression: `has(args.namespace) && args.namespace.startsWith("prod")`,
+				Effect:     "deny",
+				Reason:     "prod namespaces are off limits",
+			},
+			{
+				Name:       "read-only-ok",
+				Expression: `annotations.readOnly == true && time.getHours() >= 0`,
+				Effect:     "allow",
+			},
+		},
+	}
+	p := mustNew(t, cfg, false)
+
+	req := Request{
+		Module:      "kubernetes",
+		Tool:        "k8s_list_pods",
+		Args:        map[string]interface{}{"namespace": "prod"},
+		Identity:    &auth.Identity{Subject: "oncall", Method: auth.MethodToken},
+		Annotations: mcp.ToolAnnotation{ReadOnlyHint: &readOnly},
+	}
+	if verdict := p.EvaluateCall(req); verdict.Decision != Confirm || verdict.Reason != "prod access needs confirmation" {
+		t.Fatalf("expected confirm from first rule, got %+v", verdict)
+	}
+
+	req.Identity = &auth.Identity{Subject: "ide", Method: auth.MethodToken}
+	if verdict := p.EvaluateCall(req); verdict.Decision != Deny || verdict.Reason != "prod namespaces are off limits" {
+		t.Fatalf("expected deny from second rule, got %+v", verdict)
+	}
+
+	req.Args = map[string]interface{}{"namespace": "dev"}
+	if verdict := p.EvaluateCall(req); verdict.Decision != Allow {
+		t.Fatalf("expected allow, got %+v", verdict)
+	}
+}
+
+func TestCELRuleOnlyTightens(t *testing.T) {
+	cfg := config.PolicyConfig{
+		ConfirmTools: []string{"kubernetes/k8s_scale"},
+		Rules: []config.Rule{
+			{Name: "oncall-anything", Expression: `identity.subject == "oncall"`, Effect: "allow"},
+			{Name: "scale-is-frozen", Expression: `tool == "k8s_scale" && identity.subject != "oncall"`, Effect: "deny", Reason: "scaling is frozen"},
+		},
+	}
+	p := mustNew(t, cfg, false)
+
+	req := Request{Module: "kubernetes", Tool: "k8s_scale", Identity: &auth.Identity{Subject: "oncall", Method: auth.MethodToken}}
+	if verdict := p.EvaluateCall(req); verdict.Decision != Confirm {
+		t.Fatalf("expected an allow rule to keep confirm_tools' confirmation, got %+v", verdict)
+	}
+
+	req.Identity = &auth.Identity{Subject: "ide", Method: auth.MethodToken}
+	if verdict := p.EvaluateCall(req); verdict.Decision != Deny || verdict.Reason != "scaling is frozen" {
+		t.Fatalf("expected a deny rule to tighten confirm to deny, got %+v", verdict)
+	}
+}
+
+func TestCELRuleLooserMatchDoesNotHideStricterRule(t *testing.T) {
+	cfg := config.PolicyConfig{
+		ConfirmTools: []string{"kubernetes/*"},
+		Rules: []config.Rule{
+			{Name: "kubernetes-ok", Expression: `module == "kubernetes"`, Effect: "allow"},
+			{Name: "no-kube-system", Expression: `has(args.namespace) && args.namespace == "kube-system"`, Effect: "deny", Reason: "kube-system is off limits"},
+		},
+	}
+	p := mustNew(t, cfg, false)
+
+	req := Request{Module: "kubernetes", Tool: "k8s_list_pods", Args: map[string]interface{}{"namespace": "kube-system"}}
+	if verdict := p.EvaluateCall(req); verdict.Decision != Deny || verdict.Reason != "kube-system is off limits" {
+		t.Fatalf("expected the later deny rule to apply, got %+v", verdict)
+	}
+	req.Args = map[string]interface{}{"namespace": "default"}
+	if verdict := p.EvaluateCall(req); verdict.Decision != Confirm {
+		t.Fatalf("expected confirm_tools to stand, got %+v", verdict)
+	}
+}
+
+func TestCELRuleEvaluationErrorFailsClosed(t *testing.T) {
+	cfg := config.PolicyConfig{
+		Rules: []config.Rule{{
+			Expression: `args.namespace == "prod"`,
+			Effect:     "deny",
+		}},
+	}
+	p := mustNew(t, cfg, false)
+	if verdict := p.EvaluateCall(Request{Module: "kubernetes", Tool: "k8s_list_pods_all"}); verdict.Decision != Deny {
+		t.Fatalf("expected deny when a deny rule cannot be evaluated, got %+v", verdict)
+	}
+}
+
+func TestCELRuleCompileErrors(t *testing.T) {
+	cases := []config.Rule{
+		{Expression: `tool ==`, Effect: "deny"},
+		{Expression: `tool`, Effect: "deny"},
+		{Expression: `unknown_var == 1`, Effect: "deny"},
+		{Expression: `tool == "x"`, Effect: "block"},
+	}
+	for _, rule := range cases {
+		if _, err := New(config.PolicyConfig{Rules: []config.Rule{rule}}, false); err == nil {
+			t.Fatalf("expected compile error for %+v", rule)
+		}
+	}
+}
+
+func TestSafeModeHonorsAnnotations(t *testing.T) {
+	p := mustNew(t, config.PolicyConfig{}, true)
+
+	readOnlyUpdateHistory := mcp.NewTool("describe_update_history", mcp.WithReadOnlyHintAnnotation(true))
+	if p.EvaluateTool("kubernetes", readOnlyUpdateHistory) != Allow {
+		t.Fatalf("expected read-only tool to be allowed despite 'update' in its name")
+	}
+
+	innocentMutation := mcp.NewTool("k8s_rollout_restart",
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+	if p.EvaluateTool("kubernetes", innocentMutation) != Deny {
+		t.Fatalf("expected non-read-only tool to be denied in safe mode")
+	}
+
+	if p.EvaluateTool("kubernetes", mcp.NewTool("k8s_list_pods")) != Deny {
+		t.Fatalf("expected NewTool's default readOnlyHint=false to deny in safe mode")
+	}
+
+	unannotated := mcp.Tool{Name: "k8s_delete_pod"}
+	if p.EvaluateTool("kubernetes", unannotated) != Deny {
+		t.Fatalf("expected keyword fallback for tools without annotations")
+	}
+	if p.EvaluateTool("kubernetes", mcp.Tool{Name: "k8s_list_pods"}) != Allow {
+		t.Fatalf("expected keyword fallback to allow innocuous unannotated tool")
+	}
+
+	destructiveOnly := mcp.Tool{Name: "k8s_list_pods", Annotations: mcp.ToolAnnotation{DestructiveHint: mcp.ToBoolPtr(true)}}
+	if p.EvaluateTool("kubernetes", destructiveOnly) != Deny {
+		t.Fatalf("expected destructive hint to deny in safe mode")
+	}
+}
+
+func TestExplainReportsMatchedEntry(t *testing.T) {
+	p := mustNew(t, config.PolicyConfig{
+		DenyTools:    []string{"docker_*", "k8s_get_logs"},
+		ConfirmTools: []string{"prometheus/*"},
+		Rules: []config.Rule{
+			{Name: "no-oncall-logs", Expression: `tool == "logs_tail" && identity.subject == "oncall"`, Effect: "deny"},
+		},
+	}, true)
+
+	cases := []struct {
+		module, tool string
+		identity     *auth.Identity
+		decision     Decision
+		matched      string
+	}{
+		{"kubernetes", "k8s_scale", nil, Deny, `server.safe_mode (keyword "scale" in tool name)`},
+		{"kubernetes", "k8s_get_logs", nil, Deny, `policy.deny_tools[1] "k8s_get_logs"`},
+		{"prometheus", "prometheus_query_metric", nil, Confirm, `policy.confirm_tools[0] "prometheus/*"`},
+		{"logs", "logs_tail", &auth.Identity{Subject: "oncall"}, Deny, `policy.rules[0] "no-oncall-logs"`},
+		{"kubernetes", "k8s_list_pods", nil, Allow, ""},
+	}
+	for _, tc := range cases {
+		got := p.Explain(Request{Module: tc.module, Tool: tc.tool, Identity: tc.identity})
+		if got.Decision != tc.decision || got.Matched != tc.matched {
+			t.Fatalf("%s/%s: got %s %q, want %s %q", tc.module, tc.tool, got.Decision, got.Matched, tc.decision, tc.matched)
+		}
+	}
+}
+
+func TestProfilesResolveByIdentity(t *testing.T) {
+	cfg := config.PolicyConfig{
+		DenyTools: []string{"k8s_get_logs"},
+		Profiles: map[string]config.PolicyProfile{
+			"oncall": {
+				Identities:   []string{"token:oncall", "jwt:*@ops.example.com"},
+				SafeMode:     mcp.ToBoolPtr(false),
+				PolicyConfig: config.PolicyConfig{ConfirmTools: []string{"k8s_scale"}},
+			},
+			"ide": {
+				Identities:   []string{"dev-*"},
+				PolicyConfig: config.PolicyConfig{AllowModules: []string{"prometheus"}},
+			},
+		},
+	}
+	profiles, err := NewProfiles(cfg, true)
+	if err != nil {
+		t.Fatalf("new profiles: %v", err)
+	}
+
+	name, p := profiles.Resolve(&auth.Identity{Method: auth.MethodToken, Subject: "oncall"})
+	if name != "oncall" || p.Evaluate("kubernetes", "k8s_scale") != Confirm || p.Evaluate("kubernetes", "k8s_get_logs") != Allow {
+		t.Fatalf("expected oncall profile without safe mode, got %q", name)
+	}
+	if name, _ := profiles.Resolve(&auth.Identity{Method: auth.MethodJWT, Subject: "amy@ops.example.com"}); name != "oncall" {
+		t.Fatalf("expected method:subject glob to bind oncall, got %q", name)
+	}
+
+	name, p = profiles.Resolve(&auth.Identity{Method: auth.MethodJWT, Subject: "dev-jo"})
+	if name != "ide" || p.Evaluate("kubernetes", "k8s_list_pods") != Deny || p.Evaluate("kubernetes", "k8s_scale") != Deny {
+		t.Fatalf("expected ide profile to inherit safe mode and allowlist, got %q", name)
+	}
+
+	name, p = profiles.Resolve(nil)
+	if name != "" || p.Evaluate("kubernetes", "k8s_get_logs") != Deny {
+		t.Fatalf("expected top-level policy for anonymous callers, got %q", name)
+	}
+	if len(profiles.All()) != 3 {
+		t.Fatalf("expected top-level policy plus two profiles")
+	}
+
+	if err := profiles.SetDefault("ide"); err != nil {
+		t.Fatalf("set default: %v", err)
+	}
+	if name, _ := profiles.Resolve(nil); name != "ide" {
+		t.Fatalf("expected default profile for anonymous callers, got %q", name)
+	}
+	if len(profiles.All()) != 2 {
+		t.Fatalf("expected top-level policy to be unreachable once a default profile is set")
+	}
+	if err := profiles.SetDefault("missing"); err == nil {
+		t.Fatalf("expected error for unknown profile")
+	}
+}
@@ -0,0 +1,121 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/edgeopslabs/nexus/pkg/auth"
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+// Profiles picks the policy that applies to a caller: the first profile
+// (by name) bound to the caller's identity, else the default profile, else
+// the top-level policy.
+type Profiles struct {
+	base     *Policy
+	named    map[string]*Policy
+	bindings []binding
+	fallback string
+}
+
+type binding struct {
+	profile  string
+	patterns []string
+}
+
+func NewProfiles(cfg config.PolicyConfig, safeMode bool) (*Profiles, error) {
+	base, err := New(cfg, safeMode)
+	if err != nil {
+		return nil, err
+	}
+	profiles := &Profiles{base: base, named: make(map[string]*Policy, len(cfg.Profiles))}
+
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		profile := cfg.Profiles[name]
+		if len(profile.Profiles) > 0 {
+			return nil, fmt.Errorf("profiles.%s: profiles cannot be nested", name)
+		}
+		for _, pattern := range profile.Identities {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("profiles.%s: invalid identity pattern %q: %w", name, pattern, err)
+			}
+		}
+		profileSafeMode := safeMode
+		if profile.SafeMode != nil {
+			profileSafeMode = *profile.SafeMode
+		}
+		compiled, err := New(profile.PolicyConfig, profileSafeMode)
+		if err != nil {
+			return nil, fmt.Errorf("profiles.%s: %w", name, err)
+		}
+		profiles.named[name] = compiled
+		if len(profile.Identities) > 0 {
+			profiles.bindings = append(profiles.bindings, binding{profile: name, patterns: profile.Identities})
+		}
+	}
+	return profiles, nil
+}
+
+// SetDefault selects the profile for callers no identity binding matches,
+// such as every stdio call.
+func (p *Profiles) SetDefault(name string) error {
+	if _, ok := p.named[name]; !ok && name != "" {
+		return fmt.Errorf("unknown policy profile %q", name)
+	}
+	p.fallback = name
+	return nil
+}
+
+// Resolve returns the profile name ("" for the top-level policy) and the
+// policy for identity.
+func (p *Profiles) Resolve(identity *auth.Identity) (string, *Policy) {
+	if identity != nil {
+		for _, b := range p.bindings {
+			if auth.MatchIdentity(b.patterns, identity) {
+				return b.profile, p.named[b.profile]
+			}
+		}
+	}
+	if p.fallback != "" {
+		return p.fallback, p.named[p.fallback]
+	}
+	return "", p.base
+}
+
+// Get returns a profile by name; "" is the top-level policy.
+func (p *Profiles) Get(name string) (*Policy, bool) {
+	if name == "" {
+		return p.base, true
+	}
+	profile, ok := p.named[name]
+	return profile, ok
+}
+
+// All returns every policy a caller may end up with. The top-level policy is
+// left out once a default profile replaces it.
+func (p *Profiles) All() []*Policy {
+	var all []*Policy
+	if p.fallback == "" {
+		all = append(all, p.base)
+	}
+	for _, name := range p.sortedNames() {
+		all = append(all, p.named[name])
+	}
+	return all
+}
+
+func (p *Profiles) sortedNames() []string {
+	names := make([]string, 0, len(p.named))
+	for name := range p.named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
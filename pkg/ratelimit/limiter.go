@@ -0,0 +1,257 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/auth"
+	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/policy"
+)
+
+// LimitedError is returned when a call exceeds a rate limit or quota.
+type LimitedError struct {
+	Limit      string // e.g. rate_limits[0]
+	Kind       string // "rate" or "quota"
+	RetryAfter time.Duration
+}
+
+func (e *LimitedError) Error() string {
+	what := "rate limited"
+	if e.Kind == "quota" {
+		what = "daily quota exceeded"
+	}
+	return fmt.Sprintf("%s, retry after %d s (%s)", what, e.RetryAfterSeconds(), e.Limit)
+}
+
+// RetryAfterSeconds rounds up so callers never retry too early.
+func (e *LimitedError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+type rule struct {
+	name        string
+	key         string // identifies the rule by content, so its state survives a reload
+	tools       []string
+	identities  []string
+	perIdentity bool
+	rate        float64 // tokens per second
+	burst       float64
+	quota       int
+}
+
+type bucket struct {
+	rule   string // rule.key
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	day    string
+	used   int // calls today; only counted for rules with a quota
+}
+
+// idle reports whether dropping b would not change any decision: its
+// bucket has refilled and it holds no calls against today's quota.
+func (b *bucket) idle(now time.Time) bool {
+	full := b.rate == 0 || b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst
+	return full && (b.used == 0 || b.day != now.UTC().Format(dayFormat))
+}
+
+const (
+	dayFormat = "2006-01-02"
+	// sweepInterval is how often Allow drops idle buckets, so per-identity
+	// buckets do not pile up for callers that have gone away.
+	sweepInterval = time.Minute
+)
+
+// Limiter enforces config.RateLimit entries with token buckets and daily
+// counters kept in memory.
+type Limiter struct {
+	rules []rule
+	state *state
+}
+
+// state holds the buckets, which a reloaded Limiter takes over.
+type state struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// sweep drops idle buckets at most once per sweepInterval. The caller
+// holds mu.
+func (st *state) sweep(now time.Time) {
+	if now.Sub(st.lastSweep) < sweepInterval {
+		return
+	}
+	st.lastSweep = now
+	for key, b := range st.buckets {
+		if b.idle(now) {
+			delete(st.buckets, key)
+		}
+	}
+}
+
+// New returns nil when no limits are configured; a nil *Limiter allows
+// every call.
+func New(limits []config.RateLimit) (*Limiter, error) {
+	if len(limits) == 0 {
+		return nil, nil
+	}
+	rules := make([]rule, 0, len(limits))
+	keys := make(map[string]int, len(limits))
+	for i, limit := range limits {
+		name := fmt.Sprintf("rate_limits[%d]", i)
+		r := rule{name: name, tools: limit.Tools, identities: limit.Identities, perIdentity: limit.PerIdentity, quota: limit.DailyQuota}
+		if limit.Rate == "" && limit.DailyQuota <= 0 {
+			return nil, fmt.Errorf("%s: rate or daily_quota is required", name)
+		}
+		if limit.DailyQuota < 0 {
+			return nil, fmt.Errorf("%s: daily_quota must not be negative", name)
+		}
+		if limit.Rate != "" {
+			count, per, err := ParseRate(limit.Rate)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			r.rate = float64(count) / per.Seconds()
+			r.burst = float64(count)
+			if limit.Burst > 0 {
+				r.burst = float64(limit.Burst)
+			}
+		}
+		r.key = fmt.Sprintf("%q %q %t %g %g %d", r.tools, r.identities, r.perIdentity, r.rate, r.burst, r.quota)
+		// Identical entries still count separately.
+		keys[r.key]++
+		r.key += "#" + strconv.Itoa(keys[r.key])
+		rules = append(rules, r)
+	}
+	return &Limiter{rules: rules, state: &state{buckets: make(map[string]*bucket)}}, nil
+}
+
+// Inherit takes over the buckets and daily counters of previous, the
+// limiter this one replaces on reload, so a reload does not reset budgets.
+// Buckets of rules that changed or were removed are dropped. Calls still
+// running against previous keep counting towards the same buckets.
+func (l *Limiter) Inherit(previous *Limiter) {
+	if l == nil || previous == nil {
+		return
+	}
+	keep := make(map[string]bool, len(l.rules))
+	for _, r := range l.rules {
+		keep[r.key] = true
+	}
+	st := previous.state
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for key, b := range st.buckets {
+		if !keep[b.rule] {
+			delete(st.buckets, key)
+		}
+	}
+	l.state = st
+}
+
+// ParseRate parses "N/s", "N/m" or "N/h" (also "N/second", "N/minute", ...).
+func ParseRate(value string) (int, time.Duration, error) {
+	countText, unit, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("rate %q must look like 10/m", value)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(countText))
+	if err != nil || count <= 0 {
+		return 0, 0, fmt.Errorf("rate %q must have a positive count", value)
+	}
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "s", "sec", "second":
+		return count, time.Second, nil
+	case "m", "min", "minute":
+		return count, time.Minute, nil
+	case "h", "hour":
+		return count, time.Hour, nil
+	case "d", "day":
+		return count, 24 * time.Hour, nil
+	default:
+		return 0, 0, fmt.Errorf("rate %q has unknown unit %q", value, unit)
+	}
+}
+
+// Allow consumes one call from every matching limit, or none of them if any
+// limit is exhausted, in which case a *LimitedError is returned.
+func (l *Limiter) Allow(module, tool string, identity *auth.Identity, now time.Time) error {
+	if l == nil {
+		return nil
+	}
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+	l.state.sweep(now)
+
+	type hit struct {
+		rule   rule
+		bucket *bucket
+	}
+	var hits []hit
+	var limited *LimitedError
+	for _, r := range l.rules {
+		if len(r.tools) > 0 && !policy.MatchTool(r.tools, module, tool) {
+			continue
+		}
+		if len(r.identities) > 0 && !auth.MatchIdentity(r.identities, identity) {
+			continue
+		}
+		b := l.bucketFor(r, identity, now)
+		if err := r.check(b, now); err != nil && (limited == nil || err.RetryAfter > limited.RetryAfter) {
+			limited = err
+		}
+		hits = append(hits, hit{rule: r, bucket: b})
+	}
+	if limited != nil {
+		return limited
+	}
+	for _, h := range hits {
+		if h.rule.rate > 0 {
+			h.bucket.tokens--
+		}
+		if h.rule.quota > 0 {
+			h.bucket.used++
+		}
+	}
+	return nil
+}
+
+func (l *Limiter) bucketFor(r rule, identity *auth.Identity, now time.Time) *bucket {
+	key := r.key + "|"
+	if r.perIdentity {
+		key += identity.String()
+	}
+	b, ok := l.state.buckets[key]
+	if !ok {
+		b = &bucket{rule: r.key, rate: r.rate, burst: r.burst, tokens: r.burst, last: now}
+		l.state.buckets[key] = b
+	}
+	if r.rate > 0 {
+		b.tokens = math.Min(r.burst, b.tokens+now.Sub(b.last).Seconds()*r.rate)
+		b.last = now
+	}
+	if day := now.UTC().Format(dayFormat); b.day != day {
+		b.day = day
+		b.used = 0
+	}
+	return b
+}
+
+func (r rule) check(b *bucket, now time.Time) *LimitedError {
+	if r.quota > 0 && b.used >= r.quota {
+		midnight := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+		return &LimitedError{Limit: r.name, Kind: "quota", RetryAfter: midnight.Sub(now)}
+	}
+	if r.rate > 0 && b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / r.rate * float64(time.Second))
+		return &LimitedError{Limit: r.name, Kind: "rate", RetryAfter: wait}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edgeopslabs/nexus/pkg/auth"
+	"github.com/edgeopslabs/nexus/pkg/config"
+)
+
+func mustNew(t *testing.T, limits []config.RateLimit) *Limiter {
+	t.Helper()
+	limiter, err := New(limits)
+	if err != nil {
+		t.Fatalf("new limiter: %v", err)
+	}
+	return limiter
+}
+
+func TestTokenBucketRefills(t *testing.T) {
+	limiter := mustNew(t, []config.RateLimit{{Tools: []string{"k8s_list_pods_all"}, Rate: "2/m"}})
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.Allow("kubernetes", "k8s_list_pods_all", nil, now); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	err := limiter.Allow("kubernetes", "k8s_list_pods_all", nil, now)
+	var limited *LimitedError
+	if !errors.As(err, &limited) || limited.Kind != "rate" || limited.RetryAfterSeconds() != 30 {
+		t.Fatalf("expected rate limit with 30s retry, got %v", err)
+	}
+	if err := limiter.Allow("kubernetes", "k8s_list_pods", nil, now); err != nil {
+		t.Fatalf("unmatched tool should not be limited: %v", err)
+	}
+	if err := limiter.Allow("kubernetes", "k8s_list_pods_all", nil, now.Add(30*time.Second)); err != nil {
+		t.Fatalf("expected a token after refill: %v", err)
+	}
+}
+
+func TestPerIdentityBudgets(t *testing.T) {
+	limiter := mustNew(t, []config.RateLimit{{Tools: []string{"kubernetes/*"}, Identities: []string{"dev-*"}, PerIdentity: true, Rate: "1/h"}})
+	now := time.Now()
+	alice := &auth.Identity{Method: auth.MethodToken, Subject: "dev-alice"}
+	bob := &auth.Identity{Method: auth.MethodToken, Subject: "dev-bob"}
+	oncall := &auth.Identity{Method: auth.MethodToken, Subject: "oncall"}
+
+	if err := limiter.Allow("kubernetes", "k8s_get_logs", alice, now); err != nil {
+		t.Fatalf("alice first call: %v", err)
+	}
+	if err := limiter.Allow("kubernetes", "k8s_get_logs", alice, now); err == nil {
+		t.Fatalf("expected alice to be limited")
+	}
+	if err := limiter.Allow("kubernetes", "k8s_get_logs", bob, now); err != nil {
+		t.Fatalf("bob has his own budget: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := limiter.Allow("kubernetes", "k8s_get_logs", oncall, now); err != nil {
+			t.Fatalf("oncall is not matched by the limit: %v", err)
+		}
+	}
+}
+
+func TestDailyQuotaResetsAtMidnightUTC(t *testing.T) {
+	limiter := mustNew(t, []config.RateLimit{
+		{Tools: []string{"prometheus/*"}, DailyQuota: 1},
+		{Tools: []string{"prometheus_query_metric"}, Rate: "10/s"},
+	})
+	now := time.Date(2026, 1, 2, 23, 0, 0, 0, time.UTC)
+
+	if err := limiter.Allow("prometheus", "prometheus_query_metric", nil, now); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	err := limiter.Allow("prometheus", "prometheus_query_metric", nil, now)
+	var limited *LimitedError
+	if !errors.As(err, &limited) || limited.Kind != "quota" || limited.RetryAfterSeconds() != 3600 {
+		t.Fatalf("expected quota error retrying at midnight, got %v", err)
+	}
+	if err := limiter.Allow("prometheus", "prometheus_query_metric", nil, now.Add(time.Hour)); err != nil {
+		t.Fatalf("expected quota reset on the next day: %v", err)
+	}
+}
+
+func TestInheritKeepsBudgetsOfUnchangedRules(t *testing.T) {
+	quota := config.RateLimit{Tools: []string{"prometheus/*"}, DailyQuota: 1}
+	rate := config.RateLimit{Tools: []string{"docker/*"}, Rate: "1/h"}
+	before := mustNew(t, []config.RateLimit{quota, rate})
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	for _, module := range []string{"prometheus", "docker"} {
+		if err := before.Allow(module, "tool", nil, now); err != nil {
+			t.Fatalf("%s first call: %v", module, err)
+		}
+	}
+
+	// The quota moves to another index; the rate limit changes.
+	rate.Rate = "2/h"
+	after := mustNew(t, []config.RateLimit{rate, quota})
+	after.Inherit(before)
+	if err := after.Allow("prometheus", "tool", nil, now); err == nil {
+		t.Fatalf("expected the used quota to survive the reload")
+	}
+	for i := 0; i < 2; i++ {
+		if err := after.Allow("docker", "tool", nil, now); err != nil {
+			t.Fatalf("expected a changed rule to start with a full bucket: %v", err)
+		}
+	}
+}
+
+func TestIdleBucketsAreEvicted(t *testing.T) {
+	limiter := mustNew(t, []config.RateLimit{
+		{Tools: []string{"docker/*"}, PerIdentity: true, Rate: "60/m"},
+		{Tools: []string{"prometheus/*"}, PerIdentity: true, DailyQuota: 5},
+	})
+	now := time.Date(2026, 1, 2, 23, 0, 0, 0, time.UTC)
+	for _, subject := range []string{"a", "b", "c"} {
+		identity := &auth.Identity{Method: auth.MethodJWT, Subject: subject}
+		_ = limiter.Allow("docker", "docker_ps", identity, now)
+		_ = limiter.Allow("prometheus", "prometheus_query", identity, now)
+	}
+	if n := len(limiter.state.buckets); n != 6 {
+		t.Fatalf("expected 6 buckets, got %d", n)
+	}
+
+	// Rate buckets have refilled; quota counters still hold today's calls.
+	_ = limiter.Allow("logs", "logs_tail", nil, now.Add(2*sweepInterval))
+	if n := len(limiter.state.buckets); n != 3 {
+		t.Fatalf("expected only today's quota counters to remain, got %d", n)
+	}
+	// After midnight UTC they are dropped too.
+	_ = limiter.Allow("logs", "logs_tail", nil, now.Add(2*time.Hour))
+	if n := len(limiter.state.buckets); n != 0 {
+		t.Fatalf("expected every bucket to be evicted, got %d", n)
+	}
+}
+
+func TestNewValidatesLimits(t *testing.T) {
+	if limiter, err := New(nil); err != nil || limiter != nil {
+		t.Fatalf("expected nil limiter without config")
+	}
+	if err := (*Limiter)(nil).Allow("m", "t", nil, time.Now()); err != nil {
+		t.Fatalf("nil limiter should allow: %v", err)
+	}
+	for _, bad := range []config.RateLimit{{}, {Rate: "10"}, {Rate: "0/s"}, {Rate: "5/fortnight"}, {DailyQuota: -1}} {
+		if _, err := New([]config.RateLimit{bad}); err == nil {
+			t.Fatalf("expected error for %+v", bad)
+		}
+	}
+}
@@ -1,20 +1,58 @@
 package registry
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/edgeopslabs/nexus/pkg/metrics"
 	"github.com/edgeopslabs/nexus/pkg/types"
 )
 
 var (
 	mu       sync.RWMutex
 	modules  = make(map[string]types.NexusModule)
+	guards   = make(map[string]*sync.RWMutex)
+	loaded   []types.NexusModule
 	loadOnce sync.Once
+	current  *config.Config
+	degraded = make(map[string]*degradedModule)
+
+	// reloading serializes Reload and Retry, which run Close and Init
+	// without holding mu so that calls into other modules are not blocked.
+	reloading sync.Mutex
+)
+
+const (
+	retryBackoff    = 5 * time.Second
+	maxRetryBackoff = 5 * time.Minute
 )
 
+// DegradedModule describes an optional module whose Init failed. It is left
+// out of the loaded modules, and so of the tool list, until a retry succeeds.
+type DegradedModule struct {
+	Module    string    `json:"module"`
+	Error     string    `json:"error"`
+	Attempts  int       `json:"attempts"`
+	NextRetry time.Time `json:"next_retry"`
+}
+
+type degradedModule struct {
+	module   types.NexusModule
+	err      error
+	attempts int
+	retryAt  time.Time
+}
+
 func Register(name string, module types.NexusModule) {
 	mu.Lock()
 	defer mu.Unlock()
@@ -23,21 +61,60 @@ func Register(name string, module types.NexusModule) {
 		panic(fmt.Sprintf("module already registered: %s", name))
 	}
 	modules[name] = module
+	guards[name] = &sync.RWMutex{}
 }
 
+// Enter marks a call into the named module. Reload waits for calls to finish
+// before closing or re-initializing a module, so Close and Init never run
+// concurrently with HandleCall, GetTools or HealthCheck. The returned func
+// ends the call.
+func Enter(name string) func() {
+	mu.RLock()
+	guard := guards[name]
+	mu.RUnlock()
+	if guard == nil {
+		return func() {}
+	}
+	guard.RLock()
+	return guard.RUnlock
+}
+
+// LoadModules initializes the enabled modules once, in dependency order (see
+// initOrder), and returns them in that order. An Init failure is an error
+// only for a module whose section sets required: true; other modules that
+// fail are marked degraded and retried by Retry.
 func LoadModules(cfg *config.Config) ([]types.NexusModule, error) {
 	var loadErr error
 	loadOnce.Do(func() {
-		for name, module := range modules {
-			if toggleable, ok := module.(interface {
-				Enabled(cfg *config.Config) bool
-			}); ok && !toggleable.Enabled(cfg) {
+		mu.Lock()
+		defer mu.Unlock()
+		current = cfg
+		order, err := initOrder()
+		if err != nil {
+			loadErr = err
+			return
+		}
+		for _, name := range order {
+			module := modules[name]
+			if !enabled(module, cfg) {
 				slog.Info("module disabled", "name", name)
+				metrics.SetModuleStatus(name, "disabled")
 				continue
 			}
-			if err := module.Init(cfg); err != nil {
-				loadErr = fmt.Errorf("failed to init module %s: %w", name, err)
-				return
+			for _, instanceName := range wanted(name, module, cfg) {
+				instance := instantiate(module, instanceName)
+				if err := instance.Init(cfg); err != nil {
+					if !required(cfg, instanceName) {
+						markDegraded(degraded, instance, err, 1, time.Now())
+						continue
+					}
+					metrics.SetModuleStatus(instanceName, "failed")
+					loadErr = fmt.Errorf("failed to init module %s: %w", instanceName, err)
+					return
+				}
+				slog.Info("module loaded", "name", instanceName)
+				metrics.SetModuleStatus(instanceName, "loaded")
+				loaded = append(loaded, instance)
 			}
 		}
 	})
@@ -48,17 +125,407 @@ func LoadModules(cfg *config.Config) ([]types.NexusModule, error) {
 
 	mu.RLock()
 	defer mu.RUnlock()
+	return append([]types.NexusModule(nil), loaded...), nil
+}
+
+// Reload applies cfg to the registered modules. Newly enabled modules and
+// instances are initialized. Disabled or removed ones are closed, and loaded
+// modules whose config section (for an instance, its resolved settings) or
+// safe mode changed are re-initialized in place, in both cases once their
+// in-flight calls finish. Calls into other modules proceed meanwhile.
+// Degraded modules that are still wanted are retried. A module that fails
+// to initialize is dropped: a required one has its error returned alongside
+// the modules that remain loaded, any other is marked degraded.
+func Reload(ctx context.Context, cfg *config.Config) ([]types.NexusModule, error) {
+	reloading.Lock()
+	defer reloading.Unlock()
+
+	mu.Lock()
+	order, err := initOrder()
+	if err != nil {
+		defer mu.Unlock()
+		return append([]types.NexusModule(nil), loaded...), err
+	}
+	previous, stale := current, degraded
+	var disabled, changed, kept, added []types.NexusModule
+	seen := make(map[string]bool, len(loaded))
+	for _, module := range loaded {
+		name := module.Name()
+		seen[name] = true
+		base, _ := types.SplitInstance(name)
+		switch {
+		case !enabled(module, cfg) || !slices.Contains(wanted(base, modules[base], cfg), name):
+			disabled = append(disabled, module)
+		case previous != nil && !moduleChanged(name, previous, cfg):
+			kept = append(kept, module)
+		default:
+			changed = append(changed, module)
+		}
+	}
+	for _, name := range order {
+		module := modules[name]
+		if !enabled(module, cfg) {
+			continue
+		}
+		for _, instanceName := range wanted(name, module, cfg) {
+			if !seen[instanceName] {
+				added = append(added, instantiate(module, instanceName))
+			}
+		}
+	}
+	moduleGuards := maps.Clone(guards)
+	mu.Unlock()
 
-	loaded := make([]types.NexusModule, 0, len(modules))
-	for name, module := range modules {
-		if toggleable, ok := module.(interface {
-			Enabled(cfg *config.Config) bool
-		}); ok && !toggleable.Enabled(cfg) {
+	now := time.Now()
+	var errs []error
+	failed := make(map[string]*degradedModule)
+	next := kept
+	for _, module := range disabled {
+		name := module.Name()
+		guard := moduleGuards[name]
+		guard.Lock()
+		closeModule(ctx, module, &errs)
+		guard.Unlock()
+		slog.Info("module disabled", "name", name)
+		metrics.SetModuleStatus(name, "disabled")
+	}
+	for _, module := range changed {
+		name := module.Name()
+		guard := moduleGuards[name]
+		guard.Lock()
+		closeModule(ctx, module, &errs)
+		err := module.Init(cfg)
+		guard.Unlock()
+		if err != nil {
+			if !required(cfg, name) {
+				markDegraded(failed, module, err, 1, now)
+				continue
+			}
+			metrics.SetModuleStatus(name, "failed")
+			errs = append(errs, fmt.Errorf("failed to init module %s: %w", name, err))
+			continue
+		}
+		slog.Info("module reloaded", "name", name)
+		next = append(next, module)
+	}
+	for _, instance := range added {
+		name := instance.Name()
+		if err := instance.Init(cfg); err != nil {
+			if !required(cfg, name) {
+				attempts := 1
+				if d, ok := stale[name]; ok {
+					attempts = d.attempts + 1
+				}
+				markDegraded(failed, instance, err, attempts, now)
+				continue
+			}
+			metrics.SetModuleStatus(name, "failed")
+			errs = append(errs, fmt.Errorf("failed to init module %s: %w", name, err))
 			continue
 		}
+		seen[name] = true
 		slog.Info("module loaded", "name", name)
+		metrics.SetModuleStatus(name, "loaded")
+		next = append(next, instance)
+	}
+	for name := range stale {
+		if _, ok := failed[name]; !ok && !seen[name] {
+			slog.Info("module disabled", "name", name)
+			metrics.SetModuleStatus(name, "disabled")
+		}
+	}
+	sortModules(next, order)
+
+	mu.Lock()
+	defer mu.Unlock()
+	loaded, current, degraded = next, cfg, failed
+	return append([]types.NexusModule(nil), loaded...), errors.Join(errs...)
+}
+
+// Retry re-initializes the degraded modules whose backoff has elapsed at
+// now, with the config last loaded. It returns the loaded modules and
+// whether any module recovered.
+func Retry(now time.Time) ([]types.NexusModule, bool) {
+	reloading.Lock()
+	defer reloading.Unlock()
+
+	mu.RLock()
+	cfg := current
+	var due []*degradedModule
+	for _, d := range degraded {
+		if !now.Before(d.retryAt) {
+			due = append(due, d)
+		}
+	}
+	mu.RUnlock()
+	sort.Slice(due, func(i, j int) bool { return due[i].module.Name() < due[j].module.Name() })
+
+	failed := make(map[string]*degradedModule)
+	var recovered []types.NexusModule
+	for _, d := range due {
+		if err := d.module.Init(cfg); err != nil {
+			markDegraded(failed, d.module, err, d.attempts+1, now)
+			continue
+		}
+		name := d.module.Name()
+		slog.Info("module recovered", "name", name, "attempts", d.attempts+1)
+		metrics.SetModuleStatus(name, "loaded")
+		recovered = append(recovered, d.module)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	maps.Copy(degraded, failed)
+	for _, module := range recovered {
+		delete(degraded, module.Name())
 		loaded = append(loaded, module)
 	}
+	if len(recovered) > 0 {
+		// The order cannot have a cycle: LoadModules would have failed.
+		order, _ := initOrder()
+		sortModules(loaded, order)
+	}
+	return append([]types.NexusModule(nil), loaded...), len(recovered) > 0
+}
+
+// NextRetry returns when Retry next has a module to re-initialize, or the
+// zero time when no module is degraded.
+func NextRetry() time.Time {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var next time.Time
+	for _, d := range degraded {
+		if next.IsZero() || d.retryAt.Before(next) {
+			next = d.retryAt
+		}
+	}
+	return next
+}
+
+// Degraded lists the degraded modules by name.
+func Degraded() []DegradedModule {
+	mu.RLock()
+	defer mu.RUnlock()
 
-	return loaded, nil
+	list := make([]DegradedModule, 0, len(degraded))
+	for name, d := range degraded {
+		list = append(list, DegradedModule{
+			Module:    name,
+			Error:     d.err.Error(),
+			Attempts:  d.attempts,
+			NextRetry: d.retryAt,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Module < list[j].Module })
+	return list
+}
+
+// markDegraded records a failed Init of an optional module and schedules
+// its next attempt in into, doubling the wait after each failure.
+func markDegraded(into map[string]*degradedModule, module types.NexusModule, err error, attempts int, now time.Time) {
+	wait := retryBackoff
+	for i := 1; i < attempts && wait < maxRetryBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, maxRetryBackoff)
+	name := module.Name()
+	into[name] = &degradedModule{module: module, err: err, attempts: attempts, retryAt: now.Add(wait)}
+	slog.Warn("module degraded", "name", name, "error", err, "attempts", attempts, "retry_in", wait)
+	metrics.SetModuleStatus(name, "degraded")
+}
+
+// required reports whether the section of the named module sets required.
+// Modules without a section are optional.
+func required(cfg *config.Config, name string) bool {
+	section := reflect.ValueOf(moduleSection(cfg, name))
+	if section.Kind() != reflect.Struct {
+		return false
+	}
+	field := section.FieldByName("Required")
+	return field.IsValid() && field.Kind() == reflect.Bool && field.Bool()
+}
+
+// sortModules orders modules by the init order of their registered module,
+// then by name, so instances of one module stay together.
+func sortModules(mods []types.NexusModule, order []string) {
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		rank[name] = i
+	}
+	sort.SliceStable(mods, func(i, j int) bool {
+		a, _ := types.SplitInstance(mods[i].Name())
+		b, _ := types.SplitInstance(mods[j].Name())
+		if rank[a] != rank[b] {
+			return rank[a] < rank[b]
+		}
+		return mods[i].Name() < mods[j].Name()
+	})
+}
+
+// initOrder sorts the registered modules so that each comes after the
+// modules it depends on, and otherwise by priority, then name. Dependencies
+// on modules that are not registered are ignored; a cycle is an error. The
+// caller holds mu.
+func initOrder() ([]string, error) {
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pending := make(map[string]int, len(names))
+	dependents := make(map[string][]string)
+	for _, name := range names {
+		dependent, ok := modules[name].(types.Dependent)
+		if !ok {
+			continue
+		}
+		for _, dep := range dependent.DependsOn() {
+			if _, registered := modules[dep]; !registered || dep == name {
+				continue
+			}
+			pending[name]++
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+
+	var ready, order []string
+	for _, name := range names {
+		if pending[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool {
+			pi, pj := priority(modules[ready[i]]), priority(modules[ready[j]])
+			if pi != pj {
+				return pi < pj
+			}
+			return ready[i] < ready[j]
+		})
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+		for _, dependent := range dependents[name] {
+			if pending[dependent]--; pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	if len(order) < len(names) {
+		var cycle []string
+		for _, name := range names {
+			if pending[name] > 0 {
+				cycle = append(cycle, name)
+			}
+		}
+		return nil, fmt.Errorf("module dependency cycle among %s", strings.Join(cycle, ", "))
+	}
+	return order, nil
+}
+
+func priority(module types.NexusModule) int {
+	if p, ok := module.(types.Prioritized); ok {
+		return p.Priority()
+	}
+	return 0
+}
+
+// wanted lists the module names cfg asks for under a registered module: its
+// own name, or one name per configured instance.
+func wanted(name string, module types.NexusModule, cfg *config.Config) []string {
+	multi, ok := module.(types.MultiInstance)
+	if !ok {
+		return []string{name}
+	}
+	instances := multi.Instances(cfg)
+	if len(instances) == 0 {
+		return []string{name}
+	}
+	names := make([]string, len(instances))
+	for i, instance := range instances {
+		names[i] = types.InstanceName(name, instance)
+	}
+	return names
+}
+
+// instantiate returns the module to load under name: the registered module
+// itself, or a new instance with its own call guard. The caller holds mu.
+func instantiate(module types.NexusModule, name string) types.NexusModule {
+	_, instance := types.SplitInstance(name)
+	if instance == "" {
+		return module
+	}
+	if guards[name] == nil {
+		guards[name] = &sync.RWMutex{}
+	}
+	return module.(types.MultiInstance).NewInstance(instance)
+}
+
+func enabled(module types.NexusModule, cfg *config.Config) bool {
+	toggleable, ok := module.(interface {
+		Enabled(cfg *config.Config) bool
+	})
+	return !ok || toggleable.Enabled(cfg)
+}
+
+func closeModule(ctx context.Context, module types.NexusModule, errs *[]error) {
+	if closer, ok := module.(types.Closer); ok {
+		if err := closer.Close(ctx); err != nil {
+			*errs = append(*errs, fmt.Errorf("failed to close module %s: %w", module.Name(), err))
+		}
+	}
+}
+
+// moduleChanged reports whether the modules.<name> section or safe mode
+// differs between the two configs.
+func moduleChanged(name string, before, after *config.Config) bool {
+	if before.Server.SafeMode != after.Server.SafeMode {
+		return true
+	}
+	return !reflect.DeepEqual(moduleSection(before, name), moduleSection(after, name))
+}
+
+// moduleSection returns the modules.<name> section; for an instance such
+// as prometheus@eu, the section's Instance method resolves its settings.
+func moduleSection(cfg *config.Config, name string) any {
+	base, instance := types.SplitInstance(name)
+	sections := reflect.ValueOf(cfg.Modules)
+	for i := 0; i < sections.NumField(); i++ {
+		tag, _, _ := strings.Cut(sections.Type().Field(i).Tag.Get("yaml"), ",")
+		if tag != base {
+			continue
+		}
+		section := sections.Field(i)
+		if resolve := section.MethodByName("Instance"); instance != "" && resolve.IsValid() {
+			return resolve.Call([]reflect.Value{reflect.ValueOf(instance)})[0].Interface()
+		}
+		return section.Interface()
+	}
+	return nil
+}
+
+// Close tears down loaded modules in reverse load order. Modules that do not
+// implement types.Closer are skipped.
+func Close(ctx context.Context) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var errs []error
+	for i := len(loaded) - 1; i >= 0; i-- {
+		module := loaded[i]
+		closer, ok := module.(types.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close module %s: %w", module.Name(), err))
+			continue
+		}
+		slog.Info("module closed", "name", module.Name())
+	}
+	loaded = nil
+	degraded = make(map[string]*degradedModule)
+	return errors.Join(errs...)
 }
@@ -2,8 +2,12 @@ package registry
 
 import (
 	"context"
+	"errors"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/edgeopslabs/nexus/pkg/config"
 	"github.com/edgeopslabs/nexus/pkg/types"
@@ -32,7 +36,11 @@ func resetRegistry() {
 	mu.Lock()
 	defer mu.Unlock()
 	modules = make(map[string]types.NexusModule)
+	guards = make(map[string]*sync.RWMutex)
+	loaded = nil
 	loadOnce = sync.Once{}
+	current = nil
+	degraded = make(map[string]*degradedModule)
 }
 
 func TestLoadModulesSkipsDisabled(t *testing.T) {
@@ -69,3 +77,350 @@ func TestLoadModulesInitRunsOnce(t *testing.T) {
 		t.Fatalf("expected Init to run once, got %d", module.initRuns)
 	}
 }
+
+type closingModule struct {
+	testModule
+	name   string
+	closed *[]string
+}
+
+func (c *closingModule) Name() string { return c.name }
+func (c *closingModule) Close(_ context.Context) error {
+	*c.closed = append(*c.closed, c.name)
+	return nil
+}
+
+func TestCloseRunsInReverseLoadOrder(t *testing.T) {
+	resetRegistry()
+	var closed []string
+	Register("a", &closingModule{testModule: testModule{enabled: true}, name: "a", closed: &closed})
+	Register("b", &closingModule{testModule: testModule{enabled: true}, name: "b", closed: &closed})
+	Register("plain", &testModule{enabled: true})
+
+	loadedModules, err := LoadModules(config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("load modules: %v", err)
+	}
+	if err := Close(context.Background()); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	var order []string
+	for _, module := range loadedModules {
+		if _, ok := module.(types.Closer); ok {
+			order = append(order, module.Name())
+		}
+	}
+	if len(closed) != 2 || closed[0] != order[1] || closed[1] != order[0] {
+		t.Fatalf("expected reverse load order %v, got %v", order, closed)
+	}
+}
+
+func TestReloadReinitsChangedModules(t *testing.T) {
+	resetRegistry()
+	var closed []string
+	module := &closingModule{testModule: testModule{enabled: true}, name: "kubernetes", closed: &closed}
+	Register("kubernetes", module)
+	other := &testModule{enabled: true}
+	Register("test", other)
+
+	cfg := config.DefaultConfig()
+	if _, err := LoadModules(cfg); err != nil {
+		t.Fatalf("load modules: %v", err)
+	}
+
+	same := config.DefaultConfig()
+	if _, err := Reload(context.Background(), same); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if module.initRuns != 1 || other.initRuns != 1 {
+		t.Fatalf("expected unchanged config to skip Init, got %d/%d", module.initRuns, other.initRuns)
+	}
+
+	changed := config.DefaultConfig()
+	changed.Modules.Kubernetes.Kubeconfig = "/etc/nexus/kubeconfig"
+	if _, err := Reload(context.Background(), changed); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if module.initRuns != 2 || len(closed) != 1 {
+		t.Fatalf("expected changed module to be closed and re-initialized, got init=%d closed=%v", module.initRuns, closed)
+	}
+	if other.initRuns != 1 {
+		t.Fatalf("expected unrelated module to keep running, got %d inits", other.initRuns)
+	}
+
+	module.enabled = false
+	reloaded, err := Reload(context.Background(), changed)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if len(reloaded) != 1 || reloaded[0].Name() != "test" || len(closed) != 2 {
+		t.Fatalf("expected disabled module to be closed and dropped, got %d modules, closed=%v", len(reloaded), closed)
+	}
+}
+
+func TestReloadClosesDisabledModulesAfterInFlightCalls(t *testing.T) {
+	resetRegistry()
+	var closed []string
+	module := &closingModule{testModule: testModule{enabled: true}, name: "kubernetes", closed: &closed}
+	Register("kubernetes", module)
+	if _, err := LoadModules(config.DefaultConfig()); err != nil {
+		t.Fatalf("load modules: %v", err)
+	}
+
+	release := Enter("kubernetes")
+	module.enabled = false
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = Reload(context.Background(), config.DefaultConfig())
+	}()
+	select {
+	case <-done:
+		t.Fatal("expected Reload to wait for the in-flight call before closing")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	<-done
+	if len(closed) != 1 {
+		t.Fatalf("expected disabled module to be closed, got %v", closed)
+	}
+}
+
+func TestReloadDoesNotBlockCallsIntoOtherModules(t *testing.T) {
+	resetRegistry()
+	var closed []string
+	Register("kubernetes", &closingModule{testModule: testModule{enabled: true}, name: "kubernetes", closed: &closed})
+	Register("test", &testModule{enabled: true})
+	if _, err := LoadModules(config.DefaultConfig()); err != nil {
+		t.Fatalf("load modules: %v", err)
+	}
+
+	release := Enter("kubernetes")
+	changed := config.DefaultConfig()
+	changed.Modules.Kubernetes.Kubeconfig = "/etc/nexus/kubeconfig"
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = Reload(context.Background(), changed)
+	}()
+	// Give Reload time to wait on the kubernetes guard.
+	time.Sleep(20 * time.Millisecond)
+
+	entered := make(chan struct{})
+	go func() {
+		Enter("test")()
+		close(entered)
+	}()
+	select {
+	case <-entered:
+	case <-time.After(time.Second):
+		t.Fatal("expected a call into another module to proceed while Reload waits")
+	}
+	select {
+	case <-done:
+		t.Fatal("expected Reload to wait for the in-flight kubernetes call")
+	default:
+	}
+	release()
+	<-done
+	if len(closed) != 1 {
+		t.Fatalf("expected changed module to be closed once, got %v", closed)
+	}
+}
+
+type instanceModule struct {
+	testModule
+	instance string
+	closed   *[]string
+}
+
+func (m *instanceModule) Name() string { return types.InstanceName("prometheus", m.instance) }
+func (m *instanceModule) Instances(cfg *config.Config) []string {
+	var names []string
+	for name := range cfg.Modules.Prometheus.Instances {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+func (m *instanceModule) NewInstance(name string) types.NexusModule {
+	return &instanceModule{testModule: testModule{enabled: true}, instance: name, closed: m.closed}
+}
+func (m *instanceModule) Close(_ context.Context) error {
+	*m.closed = append(*m.closed, m.Name())
+	return nil
+}
+
+func moduleNames(modules []types.NexusModule) []string {
+	names := make([]string, len(modules))
+	for i, module := range modules {
+		names[i] = module.Name()
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestLoadModulesCreatesOneModulePerInstance(t *testing.T) {
+	resetRegistry()
+	var closed []string
+	Register("prometheus", &instanceModule{testModule: testModule{enabled: true}, closed: &closed})
+
+	cfg := config.DefaultConfig()
+	cfg.Modules.Prometheus.Instances = map[string]config.PrometheusInstance{
+		"eu": {URL: "http://prom-eu:9090"},
+		"us": {URL: "http://prom-us:9090"},
+	}
+	loadedModules, err := LoadModules(cfg)
+	if err != nil {
+		t.Fatalf("load modules: %v", err)
+	}
+	if got := moduleNames(loadedModules); strings.Join(got, ",") != "prometheus@eu,prometheus@us" {
+		t.Fatalf("expected one module per instance, got %v", got)
+	}
+
+	changed := config.DefaultConfig()
+	changed.Modules.Prometheus.Instances = map[string]config.PrometheusInstance{
+		"eu": {URL: "http://prom-eu:9090"},
+		"ap": {URL: "http://prom-ap:9090"},
+	}
+	reloaded, err := Reload(context.Background(), changed)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := moduleNames(reloaded); strings.Join(got, ",") != "prometheus@ap,prometheus@eu" {
+		t.Fatalf("expected instances to follow the config, got %v", got)
+	}
+	if len(closed) != 1 || closed[0] != "prometheus@us" {
+		t.Fatalf("expected only the removed instance to close, got %v", closed)
+	}
+}
+
+type orderedModule struct {
+	testModule
+	name     string
+	priority int
+	deps     []string
+	inits    *[]string
+}
+
+func (m *orderedModule) Name() string        { return m.name }
+func (m *orderedModule) Priority() int       { return m.priority }
+func (m *orderedModule) DependsOn() []string { return m.deps }
+func (m *orderedModule) Init(cfg *config.Config) error {
+	*m.inits = append(*m.inits, m.name)
+	return nil
+}
+
+func TestLoadModulesInitsInDependencyThenPriorityOrder(t *testing.T) {
+	resetRegistry()
+	var inits []string
+	register := func(name string, priority int, deps ...string) {
+		Register(name, &orderedModule{testModule: testModule{enabled: true}, name: name, priority: priority, deps: deps, inits: &inits})
+	}
+	register("plugins", 100)
+	register("docker", 0)
+	register("logs", 0, "kubernetes", "missing")
+	register("kubernetes", 0, "prometheus")
+	register("prometheus", 5)
+
+	loadedModules, err := LoadModules(config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("load modules: %v", err)
+	}
+	want := "docker,prometheus,kubernetes,logs,plugins"
+	if got := strings.Join(inits, ","); got != want {
+		t.Fatalf("expected init order %s, got %s", want, got)
+	}
+	for i, module := range loadedModules {
+		if module.Name() != inits[i] {
+			t.Fatalf("expected modules returned in init order, got %s at %d", module.Name(), i)
+		}
+	}
+}
+
+func TestLoadModulesRejectsDependencyCycles(t *testing.T) {
+	resetRegistry()
+	var inits []string
+	Register("a", &orderedModule{testModule: testModule{enabled: true}, name: "a", deps: []string{"b"}, inits: &inits})
+	Register("b", &orderedModule{testModule: testModule{enabled: true}, name: "b", deps: []string{"a"}, inits: &inits})
+	Register("c", &orderedModule{testModule: testModule{enabled: true}, name: "c", inits: &inits})
+
+	_, err := LoadModules(config.DefaultConfig())
+	if err == nil || !strings.Contains(err.Error(), "cycle among a, b") {
+		t.Fatalf("expected a cycle error, got %v", err)
+	}
+	if len(inits) != 0 {
+		t.Fatalf("expected no module to init, got %v", inits)
+	}
+}
+
+type failingModule struct {
+	testModule
+	name     string
+	failures int // Init fails this many times, then succeeds
+}
+
+func (m *failingModule) Name() string { return m.name }
+func (m *failingModule) Init(cfg *config.Config) error {
+	m.initRuns++
+	if m.initRuns <= m.failures {
+		return errors.New("backend unreachable")
+	}
+	return nil
+}
+
+func TestLoadModulesDegradesOptionalModulesAndRetries(t *testing.T) {
+	resetRegistry()
+	Register("plugins", &failingModule{testModule: testModule{enabled: true}, name: "plugins", failures: 2})
+	Register("test", &testModule{enabled: true})
+
+	start := time.Now()
+	loadedModules, err := LoadModules(config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("expected an optional module failure not to abort loading, got %v", err)
+	}
+	if got := moduleNames(loadedModules); strings.Join(got, ",") != "test" {
+		t.Fatalf("expected the degraded module to be left out, got %v", got)
+	}
+	list := Degraded()
+	if len(list) != 1 || list[0].Module != "plugins" || list[0].Error != "backend unreachable" || list[0].Attempts != 1 {
+		t.Fatalf("expected plugins to be degraded, got %+v", list)
+	}
+
+	if _, recovered := Retry(start); recovered {
+		t.Fatalf("expected no retry before the backoff elapses")
+	}
+	first := NextRetry()
+	if _, recovered := Retry(first); recovered {
+		t.Fatalf("expected the second attempt to fail")
+	}
+	if second := NextRetry(); second.Sub(first) != 2*retryBackoff {
+		t.Fatalf("expected the backoff to double, got %v", second.Sub(first))
+	}
+	loadedModules, recovered := Retry(NextRetry())
+	if !recovered || len(Degraded()) != 0 {
+		t.Fatalf("expected the third attempt to recover, got %+v", Degraded())
+	}
+	if got := moduleNames(loadedModules); strings.Join(got, ",") != "plugins,test" {
+		t.Fatalf("expected the recovered module to load, got %v", got)
+	}
+	if !NextRetry().IsZero() {
+		t.Fatalf("expected nothing left to retry")
+	}
+}
+
+func TestLoadModulesFailsOnRequiredModules(t *testing.T) {
+	resetRegistry()
+	Register("plugins", &failingModule{testModule: testModule{enabled: true}, name: "plugins", failures: 1})
+
+	cfg := config.DefaultConfig()
+	cfg.Modules.Plugins.Required = true
+	_, err := LoadModules(cfg)
+	if err == nil || !strings.Contains(err.Error(), "failed to init module plugins") {
+		t.Fatalf("expected a required module failure to abort loading, got %v", err)
+	}
+	if len(Degraded()) != 0 {
+		t.Fatalf("expected a required module not to be degraded")
+	}
+}
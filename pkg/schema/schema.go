@@ -0,0 +1,197 @@
+// Package schema checks YAML documents against the JSON Schemas Nexus
+// publishes for nexus.yaml and plugin manifests, keeping line and column
+// information that a JSON validator would lose.
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// Issue is one schema violation in a YAML document.
+type Issue struct {
+	Path    string // dotted key, e.g. spec.capabilities.tools[0].args[1].type
+	Line    int
+	Column  int
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", i.Line, i.Column, i.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", i.Line, i.Column, i.Path, i.Message)
+}
+
+// Reflect generates a schema for v from its yaml tags. Only fields tagged
+// jsonschema:"required" are required, and unknown keys are rejected.
+func Reflect(v any, title string) *jsonschema.Schema {
+	r := &jsonschema.Reflector{
+		FieldNameTag:               "yaml",
+		RequiredFromJSONSchemaTags: true,
+		Anonymous:                  true,
+		ExpandedStruct:             true,
+	}
+	s := r.Reflect(v)
+	s.Title = title
+	return s
+}
+
+// Validate checks node against s. It covers what the generated schemas
+// use: $ref into $defs, type, properties, additionalProperties, required,
+// items and enum. Enum values match case-insensitively, as Nexus reads
+// them; scalar types are left to the YAML decoder, which reports them
+// with the Go type.
+func Validate(s *jsonschema.Schema, node *yaml.Node) []Issue {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind == 0 {
+		return nil // empty document
+	}
+	v := validator{defs: s.Definitions}
+	v.check(s, node, "", node)
+	return v.issues
+}
+
+type validator struct {
+	defs   jsonschema.Definitions
+	issues []Issue
+}
+
+func (v *validator) add(at *yaml.Node, path, format string, args ...any) {
+	v.issues = append(v.issues, Issue{Path: path, Line: at.Line, Column: at.Column, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) resolve(s *jsonschema.Schema) *jsonschema.Schema {
+	for s != nil && s.Ref != "" {
+		name, ok := strings.CutPrefix(s.Ref, "#/$defs/")
+		if !ok {
+			return nil
+		}
+		s = v.defs[name]
+	}
+	return s
+}
+
+// check validates node; at is where problems with it are reported, which
+// for a mapping value is its key.
+func (v *validator) check(s *jsonschema.Schema, node *yaml.Node, path string, at *yaml.Node) {
+	s = v.resolve(s)
+	if s == nil || node.Kind == yaml.AliasNode || node.Tag == "!!null" {
+		return
+	}
+	switch s.Type {
+	case "object":
+		if node.Kind != yaml.MappingNode {
+			v.add(at, path, "expected a mapping")
+			return
+		}
+		v.object(s, node, path)
+	case "array":
+		if node.Kind != yaml.SequenceNode {
+			v.add(at, path, "expected a list")
+			return
+		}
+		for i, item := range node.Content {
+			v.check(s.Items, item, fmt.Sprintf("%s[%d]", path, i), item)
+		}
+	case "string", "integer", "number", "boolean":
+		if node.Kind != yaml.ScalarNode {
+			v.add(at, path, "expected a %s", s.Type)
+			return
+		}
+		v.enum(s, node, path)
+	}
+}
+
+func (v *validator) object(s *jsonschema.Schema, node *yaml.Node, path string) {
+	seen := make(map[string]bool, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keyPath := joinPath(path, key.Value)
+		seen[key.Value] = true
+		at := key
+		if value.Kind == yaml.ScalarNode {
+			at = value
+		}
+		if s.Properties != nil {
+			if prop, ok := s.Properties.Get(key.Value); ok {
+				v.check(prop, value, keyPath, at)
+				continue
+			}
+		}
+		switch {
+		case s.AdditionalProperties == jsonschema.FalseSchema:
+			v.add(key, keyPath, "%s", unknownKey(key.Value, s))
+		case s.AdditionalProperties != nil:
+			v.check(s.AdditionalProperties, value, keyPath, at)
+		}
+	}
+	for _, name := range s.Required {
+		if !seen[name] {
+			v.add(node, path, "missing required key %q", name)
+		}
+	}
+}
+
+func (v *validator) enum(s *jsonschema.Schema, node *yaml.Node, path string) {
+	// An empty value leaves the setting at its default.
+	if len(s.Enum) == 0 || node.Value == "" {
+		return
+	}
+	allowed := make([]string, 0, len(s.Enum))
+	for _, e := range s.Enum {
+		value := fmt.Sprint(e)
+		if strings.EqualFold(value, node.Value) {
+			return
+		}
+		allowed = append(allowed, value)
+	}
+	v.add(node, path, "unknown value %q; expected one of %s", node.Value, strings.Join(allowed, ", "))
+}
+
+func unknownKey(key string, s *jsonschema.Schema) string {
+	best, bestDistance := "", 3
+	if s.Properties != nil {
+		for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			if d := editDistance(key, pair.Key); d < bestDistance || (d == bestDistance && pair.Key < best) {
+				best, bestDistance = pair.Key, d
+			}
+		}
+	}
+	if best != "" {
+		return fmt.Sprintf("unknown field %q (did you mean %q?)", key, best)
+	}
+	return fmt.Sprintf("unknown field %q", key)
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
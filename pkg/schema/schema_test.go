@@ -0,0 +1,60 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+type testConfig struct {
+	Level string            `yaml:"level" jsonschema:"enum=debug,enum=info"`
+	Items []testItem        `yaml:"items"`
+	Tags  map[string]string `yaml:"tags"`
+}
+
+type testItem struct {
+	Name string `yaml:"name" jsonschema:"required"`
+	Port int    `yaml:"port"`
+}
+
+func validate(t *testing.T, data string) []Issue {
+	t.Helper()
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(data), &root); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return Validate(Reflect(&testConfig{}, "test"), &root)
+}
+
+func TestValidateAcceptsValidDocument(t *testing.T) {
+	issues := validate(t, "level: INFO\nitems:\n  - name: a\n    port: 80\ntags:\n  env: prod\n")
+	if len(issues) != 0 {
+		t.Fatalf("expected no issues, got %v", issues)
+	}
+}
+
+func TestValidateReportsIssuesWithPosition(t *testing.T) {
+	issues := validate(t, "level: trace\nitems:\n  - port: 80\n    nmae: a\ntags: [a]\n")
+	want := []string{
+		`1:8: level: unknown value "trace"; expected one of debug, info`,
+		`4:5: items[0].nmae: unknown field "nmae" (did you mean "name"?)`,
+		`3:5: items[0]: missing required key "name"`,
+		`5:1: tags: expected a mapping`,
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("expected %d issues, got %v", len(want), issues)
+	}
+	for i, issue := range issues {
+		if issue.String() != want[i] {
+			t.Fatalf("issue %d: got %q, want %q", i, issue.String(), want[i])
+		}
+	}
+}
+
+func TestReflectRejectsUnknownKeys(t *testing.T) {
+	issues := validate(t, "extra: true\n")
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, `unknown field "extra"`) {
+		t.Fatalf("expected an unknown field issue, got %v", issues)
+	}
+}
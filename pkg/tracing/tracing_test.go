@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/edgeopslabs/nexus/pkg/config"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestFromMetaContinuesTrace(t *testing.T) {
+	recorder := recordSpans(t)
+	ctx := FromMeta(context.Background(), &mcp.Meta{AdditionalFields: map[string]any{"traceparent": traceparent}})
+	_, span := Start(ctx, "tools/call k8s_get_logs")
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, got %d", len(spans))
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("expected trace from _meta, got %s", got)
+	}
+	if got := spans[0].Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Fatalf("expected remote parent span, got %s", got)
+	}
+	if ctx := FromMeta(context.Background(), nil); trace.SpanContextFromContext(ctx).IsValid() {
+		t.Fatalf("expected no span context without _meta")
+	}
+}
+
+func TestTransportCreatesClientSpanAndPropagates(t *testing.T) {
+	recorder := recordSpans(t)
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ctx, parent := Start(context.Background(), "tools/call prometheus_query_metric")
+	client := &http.Client{Transport: Transport(nil, "prometheus")}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/query?query=up", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Name() != "prometheus GET" {
+		t.Fatalf("expected client span then parent span, got %d spans", len(spans))
+	}
+	client0 := spans[0]
+	if client0.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("client span should be a child of the tool call span")
+	}
+	if !strings.Contains(received, client0.SpanContext().TraceID().String()) {
+		t.Fatalf("expected traceparent header with trace id, got %q", received)
+	}
+	for _, attr := range client0.Attributes() {
+		if attr.Key == "url.full" && strings.Contains(attr.Value.AsString(), "query=") {
+			t.Fatalf("query string should not be recorded: %s", attr.Value.AsString())
+		}
+	}
+}
+
+func TestSetupFileExporter(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	path := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := Setup(context.Background(), config.TracingConfig{Enabled: true, Exporter: "file", File: path}, "Nexus", "v0.0.1")
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	_, span := Start(context.Background(), "docker logs")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), `"docker logs"`) {
+		t.Fatalf("expected span in trace file, got %q (%v)", data, err)
+	}
+
+	if _, err := Setup(context.Background(), config.TracingConfig{Enabled: true, Exporter: "zipkin"}, "Nexus", "v0.0.1"); err == nil {
+		t.Fatalf("expected error for unknown exporter")
+	}
+}
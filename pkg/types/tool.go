@@ -2,6 +2,7 @@ package types
 
 import (
 	"context"
+	"strings"
 
 	"github.com/edgeopslabs/nexus/pkg/config"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -13,3 +14,64 @@ type NexusModule interface {
 	GetTools() []mcp.Tool
 	HandleCall(ctx context.Context, name string, args map[string]interface{}) (*mcp.CallToolResult, error)
 }
+
+// Closer is implemented by modules that hold resources which must be released
+// on shutdown. The registry calls Close in reverse load order.
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// HealthChecker is implemented by modules that can probe their backend. A
+// non-nil error marks the module down in /readyz and nexus_health.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// ArgDefaulter is implemented by modules that fill in arguments a call left
+// out, such as the Kubernetes context. It runs before policy, so rules and
+// the audit log see what the call will act on.
+type ArgDefaulter interface {
+	DefaultArgs(tool string, args map[string]interface{})
+}
+
+// Dependent is implemented by modules that must initialize after others,
+// named as registered. Dependencies on modules that are not registered are
+// ignored, so a module can order itself after an optional one.
+type Dependent interface {
+	DependsOn() []string
+}
+
+// Prioritized is implemented by modules that should initialize before
+// (lower) or after (higher) their peers; the default is 0. Dependencies take
+// precedence, and ties are broken by name.
+type Prioritized interface {
+	Priority() int
+}
+
+// MultiInstance is implemented by modules whose config section can name
+// several instances, e.g. one Prometheus per region. When Instances returns
+// names, the registry loads one module per name from NewInstance in place
+// of the registered module.
+type MultiInstance interface {
+	Instances(cfg *config.Config) []string
+	NewInstance(name string) NexusModule
+}
+
+// InstanceSeparator joins a module and one of its instances in module
+// names, e.g. "prometheus@eu".
+const InstanceSeparator = "@"
+
+// InstanceName is the module name of an instance; with no instance it is
+// the module name itself.
+func InstanceName(module, instance string) string {
+	if instance == "" {
+		return module
+	}
+	return module + InstanceSeparator + instance
+}
+
+// SplitInstance reverses InstanceName.
+func SplitInstance(name string) (module, instance string) {
+	module, instance, _ = strings.Cut(name, InstanceSeparator)
+	return module, instance
+}